@@ -3,45 +3,126 @@ package main
 import (
 	"context"
 	"log"
+	"math/big"
 	"net"
+	"strings"
 	"time"
 
-	"google.golang.org/grpc"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 
+	"mev-relay/internal/beacon"
 	"mev-relay/internal/builder"
 	"mev-relay/internal/config"
+	"mev-relay/internal/db"
 	"mev-relay/internal/pb"
+	"mev-relay/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 
+	shutdown, err := tracing.Setup(context.Background(), "builder", cfg.TracingExporter, cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+	defer shutdown(context.Background())
+
 	dbPool, err := connectDB(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal("Database connection failed:", err)
 	}
 	defer dbPool.Close()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = db.Migrate(ctx, dbPool)
+	cancel()
+	if err != nil {
+		log.Fatal("Database migration failed:", err)
+	}
+
 	lis, err := net.Listen("tcp", ":"+cfg.BuilderPort)
 	if err != nil {
 		log.Fatal("Failed to listen:", err)
 	}
 
-	server := grpc.NewServer()
+	// Accept the keepalive pings of the relay's long-lived connections and
+	// continue the traces carried in their metadata.
+	server := grpc.NewServer(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+
+	key, err := builderKey(cfg.BuilderSecretKey)
+	if err != nil {
+		log.Fatal("Invalid BUILDER_SECRET_KEY:", err)
+	}
 
 	builderService := &builder.Service{
 		Publisher: builder.NewDBPublisher(dbPool),
+		Pubkey:    key.PublicKey(),
+		BlockTime: uint64(cfg.AnvilBlockTime),
 	}
 
+	eth, err := ethclient.Dial(cfg.GethRPC)
+	if err != nil {
+		log.Fatal("Connecting to node failed:", err)
+	}
+
+	if cfg.BuilderRefundKey != "" {
+		refundKey, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.BuilderRefundKey, "0x"))
+		if err != nil {
+			log.Fatal("Invalid BUILDER_REFUND_KEY:", err)
+		}
+		builderService.Refunds = builder.NewRefundPayer(refundKey, big.NewInt(cfg.ChainID), eth)
+		log.Println("[Builder] Paying refunds from", builderService.Refunds.Address())
+	} else {
+		log.Println("[Builder] BUILDER_REFUND_KEY not set, rejecting bundles that owe a refund")
+	}
+	go pruneMinedBlocks(eth, builderService)
+
 	pb.RegisterBuilderServiceServer(server, builderService)
 
-	log.Println("Builder service running on port", cfg.BuilderPort)
+	log.Println("Builder service running on port", cfg.BuilderPort, "as", builderService.Pubkey)
 	if err := server.Serve(lis); err != nil {
 		log.Fatal("Builder service failed:", err)
 	}
 }
 
+// pruneMinedBlocks follows the chain head and drops the pending bundles of
+// blocks that have been mined.
+func pruneMinedBlocks(eth *ethclient.Client, service *builder.Service) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		head, err := eth.HeaderByNumber(ctx, nil)
+		cancel()
+		if err != nil {
+			log.Println("[Builder] Failed to read chain head:", err)
+			continue
+		}
+		service.Prune(head.Number.Uint64(), head.Time)
+	}
+}
+
+// builderKey parses the builder's identity key, generating a throwaway one
+// when none is configured.
+func builderKey(hexKey string) (*beacon.SecretKey, error) {
+	if hexKey == "" {
+		return beacon.GenerateSecretKey()
+	}
+	return beacon.SecretKeyFromHex(hexKey)
+}
+
 func connectDB(databaseURL string) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -50,6 +131,7 @@ func connectDB(databaseURL string) (*pgxpool.Pool, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.ConnConfig.Tracer = tracing.DBTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"mev-relay/internal/beacon"
+	"mev-relay/internal/config"
+)
+
+func main() {
+	cfg := config.Load()
+
+	eth, err := ethclient.Dial(cfg.GethRPC)
+	if err != nil {
+		log.Fatal("Connecting to node failed:", err)
+	}
+
+	forkVersion, err := beacon.ParseForkVersion(cfg.GenesisForkVersion)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := beacon.NewMockClient(cfg.RelayURL, eth, forkVersion, cfg.MockValidators)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Mock beacon client proposing through", cfg.RelayURL)
+	if err := client.Run(context.Background()); err != nil {
+		log.Fatal("Mock beacon client failed:", err)
+	}
+}
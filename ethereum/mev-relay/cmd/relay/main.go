@@ -1,16 +1,37 @@
 package main
 
 import (
+	"context"
 	"log"
-	"mev-relay/internal/relay"
+	"time"
+
 	"mev-relay/internal/config"
+	"mev-relay/internal/db"
+	"mev-relay/internal/relay"
+	"mev-relay/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 
+	shutdown, err := tracing.Setup(context.Background(), "relay", cfg.TracingExporter, cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+	defer shutdown(context.Background())
+
+	dbPool := db.NewPool(cfg.DatabaseURL)
+	defer dbPool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = db.Migrate(ctx, dbPool)
+	cancel()
+	if err != nil {
+		log.Fatal("Database migration failed:", err)
+	}
+
 	log.Println("Starting MEV Relay on port", cfg.RelayPort)
-	if err := relay.StartServer(cfg); err != nil {
+	if err := relay.StartServer(cfg, dbPool); err != nil {
 		log.Fatal("Relay server error:", err)
 	}
 }
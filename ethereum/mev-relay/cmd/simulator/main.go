@@ -1,24 +1,49 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 	"mev-relay/internal/config"
 	"mev-relay/internal/pb"
 	"mev-relay/internal/simulator"
+	"mev-relay/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 
+	// The simulation node is reset and mined on, which must never happen to
+	// the chain the relay and proposers follow.
+	if cfg.AnvilRPC == cfg.GethRPC {
+		log.Fatal("ANVIL_RPC must be a dedicated simulation node, not the chain node at GETH_RPC")
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), "simulator", cfg.TracingExporter, cfg.OTLPEndpoint)
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+	defer shutdown(context.Background())
+
 	lis, err := net.Listen("tcp", ":"+cfg.SimulatorPort)
 	if err != nil {
 		log.Fatal("Failed to listen:", err)
 	}
 
-	server := grpc.NewServer()
+	// Accept the keepalive pings of the relay's long-lived connections and
+	// continue the traces carried in their metadata.
+	server := grpc.NewServer(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
 
 	simulatorService := simulator.NewService(cfg)
 	pb.RegisterSimulationServiceServer(server, simulatorService)
@@ -7,6 +7,21 @@ PORT=${PORT:-8545}
 BLOCK_TIME=${BLOCK_TIME:-2}
 MNEMONIC=${MNEMONIC:-"test test test test test test test test test test test junk"}
 
+# A simulation node forks FORK_URL and never mines on its own: the simulator
+# resets it to the chain head and mines its blocks explicitly. Pending
+# transactions are mined in the order they were sent, which keeps the order
+# of the bundle the simulator mines into one block.
+if [ -n "$FORK_URL" ]; then
+  echo "Launching $CHAIN_NAME fork of $FORK_URL on port $PORT (id=$CHAIN_ID)..."
+  exec anvil \
+    --fork-url "$FORK_URL" \
+    --chain-id $CHAIN_ID \
+    --port $PORT \
+    --no-mining \
+    --order fifo \
+    --host 0.0.0.0
+fi
+
 echo "Launching $CHAIN_NAME chain on port $PORT (id=$CHAIN_ID)..."
 
 exec anvil \
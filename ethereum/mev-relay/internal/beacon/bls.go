@@ -0,0 +1,119 @@
+package beacon
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// signatureDST is the domain separation tag of the proof-of-possession BLS
+// scheme used by the consensus layer.
+var signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// PublicKey is a compressed BLS12-381 G1 point.
+type PublicKey [48]byte
+
+// Signature is a compressed BLS12-381 G2 point.
+type Signature [96]byte
+
+func (k PublicKey) String() string { return hexutil.Encode(k[:]) }
+
+func (k PublicKey) MarshalText() ([]byte, error) { return hexutil.Bytes(k[:]).MarshalText() }
+
+func (k *PublicKey) UnmarshalText(input []byte) error {
+	return hexutil.UnmarshalFixedText("PublicKey", input, k[:])
+}
+
+func (s Signature) MarshalText() ([]byte, error) { return hexutil.Bytes(s[:]).MarshalText() }
+
+func (s *Signature) UnmarshalText(input []byte) error {
+	return hexutil.UnmarshalFixedText("Signature", input, s[:])
+}
+
+// SecretKey is a BLS12-381 secret scalar.
+type SecretKey struct {
+	k *big.Int
+}
+
+// GenerateSecretKey returns a random secret key.
+func GenerateSecretKey() (*SecretKey, error) {
+	k, err := rand.Int(rand.Reader, fr.Modulus())
+	if err != nil {
+		return nil, err
+	}
+	if k.Sign() == 0 {
+		return GenerateSecretKey()
+	}
+	return &SecretKey{k: k}, nil
+}
+
+// SecretKeyFromBytes parses a 32-byte big-endian secret key.
+func SecretKeyFromBytes(b []byte) (*SecretKey, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("secret key must be 32 bytes, got %d", len(b))
+	}
+	k := new(big.Int).SetBytes(b)
+	if k.Sign() == 0 || k.Cmp(fr.Modulus()) >= 0 {
+		return nil, errors.New("secret key out of range")
+	}
+	return &SecretKey{k: k}, nil
+}
+
+// SecretKeyFromHex parses a 0x-prefixed hex secret key.
+func SecretKeyFromHex(s string) (*SecretKey, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return SecretKeyFromBytes(b)
+}
+
+// PublicKey returns the key's public key.
+func (sk *SecretKey) PublicKey() PublicKey {
+	var pk bls12381.G1Affine
+	pk.ScalarMultiplicationBase(sk.k)
+	return pk.Bytes()
+}
+
+// Sign signs msg, usually a signing root.
+func (sk *SecretKey) Sign(msg []byte) Signature {
+	h, err := bls12381.HashToG2(msg, signatureDST)
+	if err != nil {
+		// HashToG2 only fails on an oversized DST.
+		panic(err)
+	}
+	var sig bls12381.G2Affine
+	sig.ScalarMultiplication(&h, sk.k)
+	return sig.Bytes()
+}
+
+// Verify reports whether sig is pk's signature of msg. Keys and signatures
+// that are not valid subgroup points, and the infinity public key, are
+// rejected.
+func Verify(pk PublicKey, msg []byte, sig Signature) bool {
+	var p bls12381.G1Affine
+	if _, err := p.SetBytes(pk[:]); err != nil || p.IsInfinity() {
+		return false
+	}
+	var s bls12381.G2Affine
+	if _, err := s.SetBytes(sig[:]); err != nil {
+		return false
+	}
+	h, err := bls12381.HashToG2(msg, signatureDST)
+	if err != nil {
+		return false
+	}
+
+	// e(pk, H(msg)) == e(g1, sig)  <=>  e(-g1, sig) * e(pk, H(msg)) == 1
+	_, _, g1, _ := bls12381.Generators()
+	var negG1 bls12381.G1Affine
+	negG1.Neg(&g1)
+
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{negG1, p}, []bls12381.G2Affine{s, h})
+	return err == nil && ok
+}
@@ -0,0 +1,120 @@
+package beacon
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The expected keys and signatures were produced with an independent BLS
+// implementation under the proof-of-possession scheme.
+
+const testSecretKey = "0x263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3"
+
+func mustSignature(t *testing.T, s string) Signature {
+	t.Helper()
+	var sig Signature
+	if err := sig.UnmarshalText([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestSignKnownAnswers(t *testing.T) {
+	sk, err := SecretKeyFromHex(testSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sk.PublicKey().String(); got != testPubkey {
+		t.Fatalf("public key = %s, want %s", got, testPubkey)
+	}
+
+	domain := BuilderDomain([4]byte{})
+	tests := []struct {
+		name string
+		root [32]byte
+		want string
+	}{
+		{
+			name: "validator registration",
+			root: SigningRoot(testRegistration(t).HashTreeRoot(), domain),
+			want: "0x83e9b1e9accefcdfd31c26374117ed84eb1f9d7219b224c15c430b37435f2fccf725a3c96dfe71c1d1336e902cd45eed0baca795a6ad8ca4a124b958f7ef3f93a30c2f17271da760651998b71818e1c55c0fa25e9962a7bfd2b5fa5ffb4c2f10",
+		},
+		{
+			name: "builder bid",
+			root: SigningRoot(testBid(t).HashTreeRoot(), domain),
+			want: "0x91b72409a8f1f9bff71954c0f349d3348714f966c916fd60bfe25bb43245dbb0db95506c0ec6ab1812d36159d4773ce70050f51b9f2101ccf56855c45c89d39dd1e6bbab4472ac35e5951e01eea7a214382a6726e4fa2f034b6eee9492159b67",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := sk.Sign(tt.root[:])
+			if got := hexutil.Encode(sig[:]); got != tt.want {
+				t.Errorf("signature = %s, want %s", got, tt.want)
+			}
+			if !Verify(sk.PublicKey(), tt.root[:], mustSignature(t, tt.want)) {
+				t.Error("known signature does not verify")
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	sk, err := SecretKeyFromHex(testSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := SigningRoot(testRegistration(t).HashTreeRoot(), BuilderDomain([4]byte{}))
+	proposerRoot := SigningRoot(testRegistration(t).HashTreeRoot(), ComputeDomain(DomainTypeBeaconProposer, [4]byte{}, [32]byte{}))
+	sig := sk.Sign(root[:])
+
+	tampered := sig
+	tampered[len(tampered)-1] ^= 0x01
+	var infinityKey PublicKey
+	infinityKey[0] = 0xc0
+	var infinitySig Signature
+	infinitySig[0] = 0xc0
+
+	tests := []struct {
+		name string
+		pk   PublicKey
+		msg  []byte
+		sig  Signature
+	}{
+		{"tampered signature", sk.PublicKey(), root[:], tampered},
+		{"other message", sk.PublicKey(), proposerRoot[:], sig},
+		{"other key", other.PublicKey(), root[:], sig},
+		{"signature by other key", sk.PublicKey(), root[:], other.Sign(root[:])},
+		{"zero signature", sk.PublicKey(), root[:], Signature{}},
+		{"infinity signature", sk.PublicKey(), root[:], infinitySig},
+		{"zero public key", PublicKey{}, root[:], sig},
+		{"infinity public key", infinityKey, root[:], infinitySig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Verify(tt.pk, tt.msg, tt.sig) {
+				t.Error("Verify accepted an invalid signature")
+			}
+		})
+	}
+}
+
+func TestSecretKeyFromBytesRejectsOutOfRange(t *testing.T) {
+	tests := map[string]string{
+		"zero":    "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"modulus": "0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001",
+		"short":   "0x01",
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := SecretKeyFromHex(key); err == nil {
+				t.Error("accepted an invalid secret key")
+			}
+		})
+	}
+}
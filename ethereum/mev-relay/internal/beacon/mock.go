@@ -0,0 +1,267 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	mockGasLimit     = 30_000_000
+	mockPollInterval = time.Second
+	mockRetryDelay   = 2 * time.Second
+)
+
+// DevnetProposer returns the proposer of slot on a devnet without a
+// consensus layer, where the registered validators take turns in pubkey
+// order.
+func DevnetProposer(slot uint64, pubkeys []PublicKey) PublicKey {
+	sorted := append([]PublicKey(nil), pubkeys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted[slot%uint64(len(sorted))]
+}
+
+// MockClient stands in for the consensus layer of a devnet that has none.
+// Every execution block is treated as a slot, proposed in turn by a fixed
+// set of generated validators, as DevnetProposer schedules them, that
+// request their blocks from a relay through the builder API.
+type MockClient struct {
+	relayURL    string
+	eth         *ethclient.Client
+	http        *http.Client
+	forkVersion [4]byte
+	validators  []*mockValidator
+	pubkeys     []PublicKey
+}
+
+type mockValidator struct {
+	index        uint64
+	key          *SecretKey
+	registration *SignedValidatorRegistration
+}
+
+// NewMockClient generates n validators that register with the relay at
+// relayURL and propose on top of the chain served by eth.
+func NewMockClient(relayURL string, eth *ethclient.Client, genesisForkVersion [4]byte, n int) (*MockClient, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("mock beacon client needs at least one validator, got %d", n)
+	}
+
+	domain := BuilderDomain(genesisForkVersion)
+	validators := make([]*mockValidator, n)
+	pubkeys := make([]PublicKey, n)
+	for i := range validators {
+		key, err := GenerateSecretKey()
+		if err != nil {
+			return nil, err
+		}
+		pubkey := key.PublicKey()
+		pubkeys[i] = pubkey
+		message := &ValidatorRegistration{
+			FeeRecipient: common.BytesToAddress(crypto.Keccak256(pubkey[:])[12:]),
+			GasLimit:     mockGasLimit,
+			Timestamp:    uint64(time.Now().Unix()),
+			Pubkey:       pubkey,
+		}
+		root := SigningRoot(message.HashTreeRoot(), domain)
+		validators[i] = &mockValidator{
+			index:        uint64(i),
+			key:          key,
+			registration: &SignedValidatorRegistration{Message: message, Signature: key.Sign(root[:])},
+		}
+	}
+
+	return &MockClient{
+		relayURL:    relayURL,
+		eth:         eth,
+		http:        &http.Client{Timeout: 5 * time.Second},
+		forkVersion: genesisForkVersion,
+		validators:  validators,
+		pubkeys:     pubkeys,
+	}, nil
+}
+
+// Run registers the validators and proposes a block for every new head
+// until ctx is cancelled.
+func (m *MockClient) Run(ctx context.Context) error {
+	for {
+		err := m.Register(ctx)
+		if err == nil {
+			break
+		}
+		log.Printf("[MockBeacon] Registering validators: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mockRetryDelay):
+		}
+	}
+	log.Printf("[MockBeacon] Registered %d validators with %s", len(m.validators), m.relayURL)
+
+	ticker := time.NewTicker(mockPollInterval)
+	defer ticker.Stop()
+
+	var lastHead uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		head, err := m.eth.HeaderByNumber(ctx, nil)
+		if err != nil {
+			log.Printf("[MockBeacon] Reading head: %v", err)
+			continue
+		}
+		if head.Number.Uint64() <= lastHead {
+			continue
+		}
+		lastHead = head.Number.Uint64()
+
+		if err := m.Propose(ctx, lastHead+1, head.Hash()); err != nil {
+			log.Printf("[MockBeacon] Slot %d: %v", lastHead+1, err)
+		}
+	}
+}
+
+// Register posts every validator's signed registration to the relay.
+func (m *MockClient) Register(ctx context.Context) error {
+	registrations := make([]*SignedValidatorRegistration, len(m.validators))
+	for i, v := range m.validators {
+		registrations[i] = v.registration
+	}
+	return m.post(ctx, "/eth/v1/builder/validators", registrations, nil)
+}
+
+// Propose runs the builder API flow for slot as its proposer: it fetches
+// and verifies the relay's bid, signs the blinded block and retrieves the
+// payload.
+func (m *MockClient) Propose(ctx context.Context, slot uint64, parentHash common.Hash) error {
+	pubkey := DevnetProposer(slot, m.pubkeys)
+	var proposer *mockValidator
+	for _, v := range m.validators {
+		if v.registration.Message.Pubkey == pubkey {
+			proposer = v
+		}
+	}
+
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHash.Hex(), pubkey)
+	bid := new(SignedBuilderBid)
+	found, err := m.get(ctx, path, bid)
+	if err != nil {
+		return fmt.Errorf("getting header: %w", err)
+	}
+	if !found {
+		log.Printf("[MockBeacon] Slot %d: no bid from relay", slot)
+		return nil
+	}
+	if bid.Message == nil || bid.Message.Header == nil || bid.Message.Value == nil {
+		return fmt.Errorf("relay returned an incomplete bid")
+	}
+
+	root := SigningRoot(bid.Message.HashTreeRoot(), BuilderDomain(m.forkVersion))
+	if !Verify(bid.Message.Pubkey, root[:], bid.Signature) {
+		return fmt.Errorf("bid signature does not verify against relay key %s", bid.Message.Pubkey)
+	}
+	header := bid.Message.Header
+	if header.ParentHash != parentHash {
+		return fmt.Errorf("bid builds on %s, not %s", header.ParentHash.Hex(), parentHash.Hex())
+	}
+
+	// The mock block carries only the payload header, so the proposer signs
+	// the header root rather than a full beacon block root.
+	headerRoot := header.HashTreeRoot()
+	blockRoot := SigningRoot(headerRoot, ComputeDomain(DomainTypeBeaconProposer, m.forkVersion, common.Hash{}))
+	block := &SignedBlindedBeaconBlock{
+		Message: &BlindedBeaconBlock{
+			Slot:          slot,
+			ProposerIndex: proposer.index,
+			Body: &BlindedBeaconBlockBody{
+				ExecutionPayloadHeader: header,
+				BlobKZGCommitments:     bid.Message.BlobKZGCommitments,
+			},
+		},
+		Signature: proposer.key.Sign(blockRoot[:]),
+	}
+
+	payload := new(ExecutionPayloadAndBlobsBundle)
+	if err := m.post(ctx, "/eth/v1/builder/blinded_blocks", block, payload); err != nil {
+		return fmt.Errorf("getting payload: %w", err)
+	}
+	if payload.ExecutionPayload == nil || payload.ExecutionPayload.Header().HashTreeRoot() != headerRoot {
+		return fmt.Errorf("relay returned a payload that does not match header %s", header.BlockHash.Hex())
+	}
+
+	log.Printf("[MockBeacon] Slot %d: validator %d received payload %s with %d txs worth %s wei",
+		slot, proposer.index, header.BlockHash.Hex(), len(payload.ExecutionPayload.Transactions), bid.Message.Value.Int())
+	return nil
+}
+
+// get fetches a versioned response into data, reporting false when the
+// relay answers 204 No Content.
+func (m *MockClient) get(ctx context.Context, path string, data interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.relayURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	return true, decodeResponse(resp, data)
+}
+
+// post sends body and decodes the versioned response into data, if any.
+func (m *MockClient) post(ctx context.Context, path string, body, data interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.relayURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, data)
+}
+
+func decodeResponse(resp *http.Response, data interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("relay answered %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("relay answered %d", resp.StatusCode)
+	}
+	if data == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(&VersionedResponse{Data: data})
+}
@@ -0,0 +1,52 @@
+package beacon
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Domain separates signatures made for different purposes.
+type Domain [32]byte
+
+var (
+	// DomainTypeAppBuilder signs validator registrations and builder bids.
+	DomainTypeAppBuilder = [4]byte{0x00, 0x00, 0x00, 0x01}
+	// DomainTypeBeaconProposer signs beacon blocks.
+	DomainTypeBeaconProposer = [4]byte{0x00, 0x00, 0x00, 0x00}
+)
+
+// ParseForkVersion parses a 4-byte fork version such as 0x00000000.
+func ParseForkVersion(s string) ([4]byte, error) {
+	var version [4]byte
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != len(version) {
+		return version, fmt.Errorf("invalid fork version %q", s)
+	}
+	copy(version[:], b)
+	return version, nil
+}
+
+// ComputeDomain returns the signing domain for the domain type on the fork.
+func ComputeDomain(domainType [4]byte, forkVersion [4]byte, genesisValidatorsRoot common.Hash) Domain {
+	var versionChunk [32]byte
+	copy(versionChunk[:], forkVersion[:])
+	forkDataRoot := merkleize([][32]byte{versionChunk, genesisValidatorsRoot}, 0)
+
+	var domain Domain
+	copy(domain[:4], domainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain
+}
+
+// BuilderDomain is the domain of builder API signatures. It is bound to the
+// genesis fork version only, so registrations stay valid across forks.
+func BuilderDomain(genesisForkVersion [4]byte) Domain {
+	return ComputeDomain(DomainTypeAppBuilder, genesisForkVersion, common.Hash{})
+}
+
+// SigningRoot returns the message that is signed for an object root.
+func SigningRoot(objectRoot [32]byte, domain Domain) [32]byte {
+	return merkleize([][32]byte{objectRoot, domain}, 0)
+}
@@ -0,0 +1,96 @@
+package beacon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// zeroHashes[i] is the root of a subtree of depth i holding only zero chunks.
+var zeroHashes [64][32]byte
+
+func init() {
+	for i := 1; i < len(zeroHashes); i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+func hashPair(a, b [32]byte) [32]byte {
+	var buf [64]byte
+	copy(buf[:32], a[:])
+	copy(buf[32:], b[:])
+	return sha256.Sum256(buf[:])
+}
+
+// merkleize returns the SSZ merkle root of chunks padded with zero chunks to
+// limit, or to the next power of two of len(chunks) when limit is 0.
+func merkleize(chunks [][32]byte, limit int) [32]byte {
+	if limit < len(chunks) {
+		limit = len(chunks)
+	}
+	depth := 0
+	for 1<<depth < limit {
+		depth++
+	}
+	if len(chunks) == 0 {
+		return zeroHashes[depth]
+	}
+
+	layer := append([][32]byte(nil), chunks...)
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[d])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// mixInLength mixes the length of a list into its root.
+func mixInLength(root [32]byte, length int) [32]byte {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:8], uint64(length))
+	return hashPair(root, chunk)
+}
+
+// packBytes splits b into 32-byte chunks, zero-padding the last one.
+func packBytes(b []byte) [][32]byte {
+	chunks := make([][32]byte, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+	return chunks
+}
+
+// bytesRoot is the root of a fixed-size byte vector.
+func bytesRoot(b []byte) [32]byte {
+	return merkleize(packBytes(b), 0)
+}
+
+// byteListRoot is the root of a byte list of at most maxLen bytes.
+func byteListRoot(b []byte, maxLen int) [32]byte {
+	return mixInLength(merkleize(packBytes(b), (maxLen+31)/32), len(b))
+}
+
+func uint64Root(v uint64) [32]byte {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:8], v)
+	return chunk
+}
+
+// uint256Root encodes v little-endian; values are assumed to fit 256 bits.
+func uint256Root(v *big.Int) [32]byte {
+	var chunk [32]byte
+	if v == nil {
+		return chunk
+	}
+	be := v.FillBytes(make([]byte, 32))
+	for i := range be {
+		chunk[i] = be[31-i]
+	}
+	return chunk
+}
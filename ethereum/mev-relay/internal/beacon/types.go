@@ -0,0 +1,246 @@
+package beacon
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SSZ list limits of the Deneb execution payload.
+const (
+	maxExtraDataBytes          = 32
+	maxBytesPerTransaction     = 1 << 30
+	maxTransactionsPerPayload  = 1 << 20
+	maxWithdrawalsPerPayload   = 16
+	maxBlobCommitmentsPerBlock = 4096
+)
+
+// U256 is a 256-bit unsigned integer encoded as a decimal string.
+type U256 big.Int
+
+func NewU256(v *big.Int) *U256 { return (*U256)(new(big.Int).Set(v)) }
+
+func (u *U256) Int() *big.Int { return (*big.Int)(u) }
+
+func (u U256) MarshalText() ([]byte, error) { return []byte((*big.Int)(&u).String()), nil }
+
+func (u *U256) UnmarshalText(input []byte) error {
+	if _, ok := (*big.Int)(u).SetString(string(input), 10); !ok {
+		return hexutil.ErrSyntax
+	}
+	return nil
+}
+
+// ValidatorRegistration is a validator's preferences for the blocks it is
+// offered.
+type ValidatorRegistration struct {
+	FeeRecipient common.Address `json:"fee_recipient"`
+	GasLimit     uint64         `json:"gas_limit,string"`
+	Timestamp    uint64         `json:"timestamp,string"`
+	Pubkey       PublicKey      `json:"pubkey"`
+}
+
+type SignedValidatorRegistration struct {
+	Message   *ValidatorRegistration `json:"message"`
+	Signature Signature              `json:"signature"`
+}
+
+func (r *ValidatorRegistration) HashTreeRoot() [32]byte {
+	return merkleize([][32]byte{
+		bytesRoot(r.FeeRecipient[:]),
+		uint64Root(r.GasLimit),
+		uint64Root(r.Timestamp),
+		bytesRoot(r.Pubkey[:]),
+	}, 0)
+}
+
+// ExecutionPayloadHeader is the Deneb execution payload header.
+type ExecutionPayloadHeader struct {
+	ParentHash       common.Hash    `json:"parent_hash"`
+	FeeRecipient     common.Address `json:"fee_recipient"`
+	StateRoot        common.Hash    `json:"state_root"`
+	ReceiptsRoot     common.Hash    `json:"receipts_root"`
+	LogsBloom        types.Bloom    `json:"logs_bloom"`
+	PrevRandao       common.Hash    `json:"prev_randao"`
+	BlockNumber      uint64         `json:"block_number,string"`
+	GasLimit         uint64         `json:"gas_limit,string"`
+	GasUsed          uint64         `json:"gas_used,string"`
+	Timestamp        uint64         `json:"timestamp,string"`
+	ExtraData        hexutil.Bytes  `json:"extra_data"`
+	BaseFeePerGas    *U256          `json:"base_fee_per_gas"`
+	BlockHash        common.Hash    `json:"block_hash"`
+	TransactionsRoot common.Hash    `json:"transactions_root"`
+	WithdrawalsRoot  common.Hash    `json:"withdrawals_root"`
+	BlobGasUsed      uint64         `json:"blob_gas_used,string"`
+	ExcessBlobGas    uint64         `json:"excess_blob_gas,string"`
+}
+
+func (h *ExecutionPayloadHeader) HashTreeRoot() [32]byte {
+	var baseFee *big.Int
+	if h.BaseFeePerGas != nil {
+		baseFee = h.BaseFeePerGas.Int()
+	}
+	return merkleize([][32]byte{
+		h.ParentHash,
+		bytesRoot(h.FeeRecipient[:]),
+		h.StateRoot,
+		h.ReceiptsRoot,
+		bytesRoot(h.LogsBloom[:]),
+		h.PrevRandao,
+		uint64Root(h.BlockNumber),
+		uint64Root(h.GasLimit),
+		uint64Root(h.GasUsed),
+		uint64Root(h.Timestamp),
+		byteListRoot(h.ExtraData, maxExtraDataBytes),
+		uint256Root(baseFee),
+		h.BlockHash,
+		h.TransactionsRoot,
+		h.WithdrawalsRoot,
+		uint64Root(h.BlobGasUsed),
+		uint64Root(h.ExcessBlobGas),
+	}, 0)
+}
+
+// Withdrawal is a consensus layer withdrawal included in a payload.
+type Withdrawal struct {
+	Index          uint64         `json:"index,string"`
+	ValidatorIndex uint64         `json:"validator_index,string"`
+	Address        common.Address `json:"address"`
+	Amount         uint64         `json:"amount,string"`
+}
+
+// ExecutionPayload is the Deneb execution payload a header commits to.
+type ExecutionPayload struct {
+	ParentHash    common.Hash     `json:"parent_hash"`
+	FeeRecipient  common.Address  `json:"fee_recipient"`
+	StateRoot     common.Hash     `json:"state_root"`
+	ReceiptsRoot  common.Hash     `json:"receipts_root"`
+	LogsBloom     types.Bloom     `json:"logs_bloom"`
+	PrevRandao    common.Hash     `json:"prev_randao"`
+	BlockNumber   uint64          `json:"block_number,string"`
+	GasLimit      uint64          `json:"gas_limit,string"`
+	GasUsed       uint64          `json:"gas_used,string"`
+	Timestamp     uint64          `json:"timestamp,string"`
+	ExtraData     hexutil.Bytes   `json:"extra_data"`
+	BaseFeePerGas *U256           `json:"base_fee_per_gas"`
+	BlockHash     common.Hash     `json:"block_hash"`
+	Transactions  []hexutil.Bytes `json:"transactions"`
+	Withdrawals   []*Withdrawal   `json:"withdrawals"`
+	BlobGasUsed   uint64          `json:"blob_gas_used,string"`
+	ExcessBlobGas uint64          `json:"excess_blob_gas,string"`
+}
+
+// Header returns the header committing to the payload.
+func (p *ExecutionPayload) Header() *ExecutionPayloadHeader {
+	return &ExecutionPayloadHeader{
+		ParentHash:       p.ParentHash,
+		FeeRecipient:     p.FeeRecipient,
+		StateRoot:        p.StateRoot,
+		ReceiptsRoot:     p.ReceiptsRoot,
+		LogsBloom:        p.LogsBloom,
+		PrevRandao:       p.PrevRandao,
+		BlockNumber:      p.BlockNumber,
+		GasLimit:         p.GasLimit,
+		GasUsed:          p.GasUsed,
+		Timestamp:        p.Timestamp,
+		ExtraData:        p.ExtraData,
+		BaseFeePerGas:    p.BaseFeePerGas,
+		BlockHash:        p.BlockHash,
+		TransactionsRoot: TransactionsRoot(p.Transactions),
+		WithdrawalsRoot:  WithdrawalsRoot(p.Withdrawals),
+		BlobGasUsed:      p.BlobGasUsed,
+		ExcessBlobGas:    p.ExcessBlobGas,
+	}
+}
+
+// TransactionsRoot is the SSZ root of a payload's transaction list.
+func TransactionsRoot(txs []hexutil.Bytes) common.Hash {
+	roots := make([][32]byte, len(txs))
+	for i, tx := range txs {
+		roots[i] = byteListRoot(tx, maxBytesPerTransaction)
+	}
+	return mixInLength(merkleize(roots, maxTransactionsPerPayload), len(txs))
+}
+
+// WithdrawalsRoot is the SSZ root of a payload's withdrawal list.
+func WithdrawalsRoot(withdrawals []*Withdrawal) common.Hash {
+	roots := make([][32]byte, len(withdrawals))
+	for i, w := range withdrawals {
+		roots[i] = merkleize([][32]byte{
+			uint64Root(w.Index),
+			uint64Root(w.ValidatorIndex),
+			bytesRoot(w.Address[:]),
+			uint64Root(w.Amount),
+		}, 0)
+	}
+	return mixInLength(merkleize(roots, maxWithdrawalsPerPayload), len(withdrawals))
+}
+
+// BuilderBid is the Deneb bid a relay offers a proposer.
+type BuilderBid struct {
+	Header             *ExecutionPayloadHeader `json:"header"`
+	BlobKZGCommitments []hexutil.Bytes         `json:"blob_kzg_commitments"`
+	Value              *U256                   `json:"value"`
+	Pubkey             PublicKey               `json:"pubkey"`
+}
+
+type SignedBuilderBid struct {
+	Message   *BuilderBid `json:"message"`
+	Signature Signature   `json:"signature"`
+}
+
+func (b *BuilderBid) HashTreeRoot() [32]byte {
+	commitments := make([][32]byte, len(b.BlobKZGCommitments))
+	for i, c := range b.BlobKZGCommitments {
+		commitments[i] = bytesRoot(c)
+	}
+	return merkleize([][32]byte{
+		b.Header.HashTreeRoot(),
+		mixInLength(merkleize(commitments, maxBlobCommitmentsPerBlock), len(commitments)),
+		uint256Root(b.Value.Int()),
+		bytesRoot(b.Pubkey[:]),
+	}, 0)
+}
+
+// SignedBlindedBeaconBlock is a proposer's signed block committing to a
+// payload header. Only the fields used by the relay are decoded; the rest
+// of the block body is ignored.
+type SignedBlindedBeaconBlock struct {
+	Message   *BlindedBeaconBlock `json:"message"`
+	Signature Signature           `json:"signature"`
+}
+
+type BlindedBeaconBlock struct {
+	Slot          uint64                  `json:"slot,string"`
+	ProposerIndex uint64                  `json:"proposer_index,string"`
+	ParentRoot    common.Hash             `json:"parent_root"`
+	StateRoot     common.Hash             `json:"state_root"`
+	Body          *BlindedBeaconBlockBody `json:"body"`
+}
+
+type BlindedBeaconBlockBody struct {
+	ExecutionPayloadHeader *ExecutionPayloadHeader `json:"execution_payload_header"`
+	BlobKZGCommitments     []hexutil.Bytes         `json:"blob_kzg_commitments"`
+}
+
+// BlobsBundle carries the blobs of a payload's blob transactions.
+type BlobsBundle struct {
+	Commitments []hexutil.Bytes `json:"commitments"`
+	Proofs      []hexutil.Bytes `json:"proofs"`
+	Blobs       []hexutil.Bytes `json:"blobs"`
+}
+
+// ExecutionPayloadAndBlobsBundle is the unblinded payload returned to the
+// proposer.
+type ExecutionPayloadAndBlobsBundle struct {
+	ExecutionPayload *ExecutionPayload `json:"execution_payload"`
+	BlobsBundle      *BlobsBundle      `json:"blobs_bundle"`
+}
+
+// VersionedResponse wraps builder API responses with their fork.
+type VersionedResponse struct {
+	Version string      `json:"version"`
+	Data    interface{} `json:"data"`
+}
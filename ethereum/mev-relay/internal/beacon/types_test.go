@@ -0,0 +1,97 @@
+package beacon
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The expected roots were computed with an independent SSZ implementation
+// from the consensus and builder spec container definitions.
+
+const testPubkey = "0xa491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a"
+
+func mustPubkey(t *testing.T, s string) PublicKey {
+	t.Helper()
+	var pk PublicKey
+	if err := pk.UnmarshalText([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	return pk
+}
+
+func testRegistration(t *testing.T) *ValidatorRegistration {
+	return &ValidatorRegistration{
+		FeeRecipient: common.HexToAddress("0xabcf8e0d4e9587369b2301d0790347320302cc09"),
+		GasLimit:     30_000_000,
+		Timestamp:    1_700_000_000,
+		Pubkey:       mustPubkey(t, testPubkey),
+	}
+}
+
+func testHeader() *ExecutionPayloadHeader {
+	var bloom types.Bloom
+	bloom[0], bloom[255] = 0x01, 0x80
+	return &ExecutionPayloadHeader{
+		ParentHash:       common.BytesToHash(bytes.Repeat([]byte{0x11}, 32)),
+		FeeRecipient:     common.HexToAddress("0xabcf8e0d4e9587369b2301d0790347320302cc09"),
+		StateRoot:        common.BytesToHash(bytes.Repeat([]byte{0x22}, 32)),
+		ReceiptsRoot:     common.BytesToHash(bytes.Repeat([]byte{0x33}, 32)),
+		LogsBloom:        bloom,
+		PrevRandao:       common.BytesToHash(bytes.Repeat([]byte{0x44}, 32)),
+		BlockNumber:      42,
+		GasLimit:         30_000_000,
+		GasUsed:          21_000,
+		Timestamp:        1_700_000_012,
+		ExtraData:        hexutil.Bytes("mev-relay"),
+		BaseFeePerGas:    NewU256(big.NewInt(7_000_000_000)),
+		BlockHash:        common.BytesToHash(bytes.Repeat([]byte{0x55}, 32)),
+		TransactionsRoot: common.BytesToHash(bytes.Repeat([]byte{0x66}, 32)),
+		WithdrawalsRoot:  common.BytesToHash(bytes.Repeat([]byte{0x77}, 32)),
+		BlobGasUsed:      131_072,
+	}
+}
+
+func testBid(t *testing.T) *BuilderBid {
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	return &BuilderBid{
+		Header: testHeader(),
+		BlobKZGCommitments: []hexutil.Bytes{
+			bytes.Repeat([]byte{0xc0}, 48),
+			bytes.Repeat([]byte{0xc1}, 48),
+		},
+		Value:  NewU256(value),
+		Pubkey: mustPubkey(t, testPubkey),
+	}
+}
+
+func TestHashTreeRoots(t *testing.T) {
+	tests := []struct {
+		name string
+		root [32]byte
+		want string
+	}{
+		{"validator registration", testRegistration(t).HashTreeRoot(), "0x82ae5f50246d657beb2d2fe8f363aa9b566d40191b71cce10dd70476ba68c4ae"},
+		{"execution payload header", testHeader().HashTreeRoot(), "0xf1ff44fa0242d971759da5d58bbcc01367245c25b5b881bcdfb9816502895866"},
+		{"builder bid", testBid(t).HashTreeRoot(), "0xdaf280e2b24bb16bde5553b329da5f33394bdb5aaa379f07a30ec53eeb526ae9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hexutil.Encode(tt.root[:]); got != tt.want {
+				t.Errorf("root = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistrationSigningRoot(t *testing.T) {
+	root := SigningRoot(testRegistration(t).HashTreeRoot(), BuilderDomain([4]byte{}))
+	const want = "0x5724f2b53ae8bcfdb695423df891a5716993fddd676fc13c3a493bf2d3b6ff44"
+	if got := hexutil.Encode(root[:]); got != want {
+		t.Errorf("signing root = %s, want %s", got, want)
+	}
+}
@@ -14,12 +14,36 @@ type BlockCandidate struct {
 // BuildCandidateBlock creates a new candidate block from queued bundles
 func BuildCandidateBlock(bundles []*pb.BundleSubmission) *BlockCandidate {
 	return &BlockCandidate{
-		Bundles: bundles,
+		Bundles: mergeMatched(bundles),
 	}
 }
 
+// mergeMatched keeps a single bundle per shared user transaction: the user
+// transaction on its own and each backrun carrying it compete, and only the
+// one paying the proposer the most after refunds stays in the block.
+func mergeMatched(bundles []*pb.BundleSubmission) []*pb.BundleSubmission {
+	best := make(map[string]*pb.BundleSubmission)
+	for _, bundle := range bundles {
+		if bundle.MatchedTxHash == "" {
+			continue
+		}
+		current, ok := best[bundle.MatchedTxHash]
+		if !ok || proposerValue(bundle).Cmp(proposerValue(current)) > 0 {
+			best[bundle.MatchedTxHash] = bundle
+		}
+	}
+
+	merged := make([]*pb.BundleSubmission, 0, len(bundles))
+	for _, bundle := range bundles {
+		if bundle.MatchedTxHash == "" || best[bundle.MatchedTxHash] == bundle {
+			merged = append(merged, bundle)
+		}
+	}
+	return merged
+}
+
 // GenerateBlockHash simulates producing a deterministic hash for a built block
 func GenerateBlockHash(bundleID string) string {
 	sum := sha256.Sum256([]byte(bundleID))
-	return "0x" + hex.EncodeToString(sum[:8]) // short hash for readability
+	return "0x" + hex.EncodeToString(sum[:])
 }
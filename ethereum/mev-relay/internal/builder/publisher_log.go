@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 
@@ -9,7 +10,7 @@ import (
 
 type LogPublisher struct{}
 
-func (l *LogPublisher) Publish(result *pb.BuildResult) error {
+func (l *LogPublisher) Publish(_ context.Context, result *pb.BuildResult) error {
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return err
@@ -19,9 +19,10 @@ func NewDBPublisher(pool *pgxpool.Pool) *DBPublisher {
 	return &DBPublisher{db: pool}
 }
 
-// Publish inserts the build result into the database
-func (p *DBPublisher) Publish(result *pb.BuildResult) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// Publish inserts the build result into the database. The insert is part of
+// the trace in ctx but outlives the caller's cancellation.
+func (p *DBPublisher) Publish(ctx context.Context, result *pb.BuildResult) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
 	defer cancel()
 
 	query := `
@@ -30,15 +31,34 @@ func (p *DBPublisher) Publish(result *pb.BuildResult) error {
 		included,
 		inclusion_reason,
 		inclusion_latency_ms,
+		slot,
+		block_number,
+		bundle_id,
+		num_tx,
+		gas_used,
+		value,
+		builder_pubkey,
+		proposer_pubkey,
+		proposer_fee_recipient,
 		timestamp
-	) VALUES ($1, $2, $3, $4, NOW());
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10::text, '')::numeric, $11, $12, $13, NOW());
 	`
 
+	// On the local chain a slot is the number of the block being built.
 	_, err := p.db.Exec(ctx, query,
 		result.BlockHash,
 		result.Included,
 		result.InclusionReason,
 		result.InclusionLatencyMs,
+		int64(result.BlockNumber),
+		int64(result.BlockNumber),
+		result.BundleId,
+		len(result.Txs),
+		int64(result.GasUsed),
+		result.Value,
+		result.BuilderPubkey,
+		result.ProposerPubkey,
+		result.ProposerFeeRecipient,
 	)
 
 	if err != nil {
@@ -1,14 +1,39 @@
 package builder
 
 import (
+	"math/big"
 	"sort"
+
 	"mev-relay/internal/pb"
 )
 
-// RankBundles sorts bundles in descending order of profit
+// RankBundles sorts bundles in descending order of the payment they make to
+// the proposer. Bundles without a coinbase diff fall back to their ETH profit.
 func RankBundles(bundles []*pb.BundleSubmission) []*pb.BundleSubmission {
 	sort.SliceStable(bundles, func(i, j int) bool {
-		return bundles[i].ProfitEth > bundles[j].ProfitEth
+		return proposerValue(bundles[i]).Cmp(proposerValue(bundles[j])) > 0
 	})
 	return bundles
 }
+
+// proposerValue returns the bundle's coinbase diff in wei, less the refund
+// owed to the user of a backrun.
+func proposerValue(bundle *pb.BundleSubmission) *big.Int {
+	if diff, ok := new(big.Int).SetString(bundle.CoinbaseDiff, 10); ok {
+		return diff.Sub(diff, Refund(bundle))
+	}
+
+	wei, _ := new(big.Float).Mul(big.NewFloat(bundle.ProfitEth), big.NewFloat(1e18)).Int(nil)
+	return wei.Sub(wei, Refund(bundle))
+}
+
+// Refund returns the wei owed to the user whose transaction a backrun bundle
+// carries: the refund percentage of what the backrun pays the coinbase.
+func Refund(bundle *pb.BundleSubmission) *big.Int {
+	value, ok := new(big.Int).SetString(bundle.BackrunValue, 10)
+	if !ok || value.Sign() <= 0 || bundle.RefundPercent <= 0 {
+		return new(big.Int)
+	}
+	value.Mul(value, big.NewInt(int64(bundle.RefundPercent)))
+	return value.Div(value, big.NewInt(100))
+}
@@ -0,0 +1,78 @@
+package builder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ChainReader is the view of the chain refund transactions are signed
+// against; an ethclient.Client satisfies it.
+type ChainReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// RefundPayer signs the transactions through which the builder pays the
+// refunds of backruns to their users, from its own funded account.
+type RefundPayer struct {
+	key    *ecdsa.PrivateKey
+	signer types.Signer
+	chain  ChainReader
+}
+
+// NewRefundPayer returns a payer spending from the account of key on the
+// given chain.
+func NewRefundPayer(key *ecdsa.PrivateKey, chainID *big.Int, chain ChainReader) *RefundPayer {
+	return &RefundPayer{
+		key:    key,
+		signer: types.LatestSignerForChainID(chainID),
+		chain:  chain,
+	}
+}
+
+// Address returns the account refunds are paid from.
+func (p *RefundPayer) Address() common.Address {
+	return crypto.PubkeyToAddress(p.key.PublicKey)
+}
+
+// Pay signs a transaction transferring amount to recipient, to be appended
+// to a block built on the current head. Every build of a block signs its
+// refund afresh, so competing blocks may share the refund's nonce.
+func (p *RefundPayer) Pay(ctx context.Context, recipient common.Address, amount *big.Int) (*types.Transaction, error) {
+	head, err := p.chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading chain head: %w", err)
+	}
+	nonce, err := p.chain.PendingNonceAt(ctx, p.Address())
+	if err != nil {
+		return nil, fmt.Errorf("reading refund account nonce: %w", err)
+	}
+
+	// The base fee can rise by an eighth per block; doubling it keeps the
+	// refund includable for a few blocks past the head.
+	feeCap := new(big.Int)
+	if head.BaseFee != nil {
+		feeCap.Mul(head.BaseFee, big.NewInt(2))
+	}
+
+	tx, err := types.SignNewTx(p.key, p.signer, &types.DynamicFeeTx{
+		ChainID:   p.signer.ChainID(),
+		Nonce:     nonce,
+		GasTipCap: new(big.Int),
+		GasFeeCap: feeCap,
+		Gas:       params.TxGas,
+		To:        &recipient,
+		Value:     amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing refund: %w", err)
+	}
+	return tx, nil
+}
@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+
+	"mev-relay/internal/pb"
+)
+
+// fakeChain is a chain head at block 100 with a base fee of 1 gwei.
+type fakeChain struct{ nonce uint64 }
+
+func (c fakeChain) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), Time: 1_000, BaseFee: big.NewInt(params.GWei), GasLimit: 30_000_000}, nil
+}
+
+func (c fakeChain) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return c.nonce, nil
+}
+
+func TestSubmitBundlePaysRefund(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := fakeChain{nonce: 7}
+	s := &Service{Refunds: NewRefundPayer(key, params.AllDevChainProtocolChanges.ChainID, chain)}
+	user := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	// A backrun paying the coinbase 1 ETH owes the user 0.9 ETH.
+	result, err := s.SubmitBundle(context.Background(), &pb.BundleSubmission{
+		BundleId:        "0x01",
+		CoinbaseDiff:    "1000000000000000000",
+		BackrunValue:    "1000000000000000000",
+		RefundPercent:   90,
+		RefundRecipient: user.Hex(),
+		TargetBlock:     101,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Txs) != 1 {
+		t.Fatalf("block carries %d txs, want the refund only", len(result.Txs))
+	}
+	if result.GasUsed != params.TxGas {
+		t.Errorf("gas used = %d, want %d", result.GasUsed, params.TxGas)
+	}
+
+	// Execute the block's refund against a state funding the refund account.
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := s.Refunds.Address()
+	statedb.SetBalance(payer, uint256.NewInt(params.Ether*10), tracing.BalanceChangeUnspecified)
+	statedb.SetNonce(payer, chain.nonce, tracing.NonceChangeUnspecified)
+
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(hexutil.MustDecode(result.Txs[0])); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash().Hex() != result.RefundTxHash {
+		t.Errorf("refund tx hash = %s, want %s", result.RefundTxHash, tx.Hash().Hex())
+	}
+
+	head, _ := chain.HeaderByNumber(context.Background(), nil)
+	header := &types.Header{Number: big.NewInt(101), Time: head.Time + 12, BaseFee: head.BaseFee, GasLimit: head.GasLimit, Difficulty: new(big.Int)}
+	config := params.AllDevChainProtocolChanges
+	msg, err := core.TransactionToMessage(&tx, types.LatestSigner(config), header.BaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != payer {
+		t.Errorf("refund sent by %s, want %s", msg.From, payer)
+	}
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, nil, &common.Address{}), statedb, config, vm.Config{})
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := uint256.NewInt(900_000_000_000_000_000)
+	if got := statedb.GetBalance(user); !got.Eq(want) {
+		t.Errorf("user balance = %s, want %s", got, want)
+	}
+}
+
+func TestSubmitBundleRejectsRefundWithoutPayer(t *testing.T) {
+	s := &Service{}
+	result, err := s.SubmitBundle(context.Background(), &pb.BundleSubmission{
+		BundleId:        "0x01",
+		CoinbaseDiff:    "1000",
+		BackrunValue:    "1000",
+		RefundPercent:   90,
+		RefundRecipient: "0x00000000000000000000000000000000000000aa",
+		TargetBlock:     101,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Included {
+		t.Error("bundle owing a refund was included by a builder that cannot pay it")
+	}
+}
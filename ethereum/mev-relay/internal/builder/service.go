@@ -2,23 +2,40 @@ package builder
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"mev-relay/internal/beacon"
 	"mev-relay/internal/pb"
 )
 
 type Publisher interface {
-	Publish(result *pb.BuildResult) error
+	Publish(ctx context.Context, result *pb.BuildResult) error
 }
 
 type Service struct {
 	pb.UnimplementedBuilderServiceServer
-	mu        sync.Mutex
-	pending   []*pb.BundleSubmission
+	mu sync.Mutex
+	// pending holds the bundles competing for each target block until that
+	// block is mined; every build for a target considers all of them.
+	pending   map[uint64][]*pb.BundleSubmission
+	head      uint64 // latest mined block seen by Prune
+	headTime  uint64 // timestamp of head
 	history   []*pb.BuildResult
 	Publisher Publisher
+	// Pubkey identifies the builder in the blocks it submits.
+	Pubkey beacon.PublicKey
+	// BlockTime is the number of seconds between blocks, from which the
+	// timestamps of future blocks are predicted.
+	BlockTime uint64
+	// Refunds pays the users of backruns; without it, bundles owing a
+	// refund are rejected.
+	Refunds *RefundPayer
 }
 
 func (s *Service) SubmitBundle(ctx context.Context, req *pb.BundleSubmission) (*pb.BuildResult, error) {
@@ -27,31 +44,231 @@ func (s *Service) SubmitBundle(ctx context.Context, req *pb.BundleSubmission) (*
 
 	log.Printf("[Builder] Received bundle: %s, profit=%.6f ETH", req.BundleId, req.ProfitEth)
 
-	s.pending = append(s.pending, req)
+	if reverted := disallowedRevert(req); reverted != "" {
+		log.Printf("[Builder] Rejected bundle %s: tx %s reverted", req.BundleId, reverted)
+		return &pb.BuildResult{
+			Included:        false,
+			InclusionReason: "tx " + reverted + " reverted and is not allowed to revert",
+		}, nil
+	}
+
+	if req.TargetBlock <= s.head {
+		log.Printf("[Builder] Rejected bundle %s: target block %d already mined", req.BundleId, req.TargetBlock)
+		return &pb.BuildResult{
+			Included:        false,
+			InclusionReason: fmt.Sprintf("target block %d already mined (head %d)", req.TargetBlock, s.head),
+		}, nil
+	}
+
+	if Refund(req).Sign() > 0 && s.Refunds == nil {
+		log.Printf("[Builder] Rejected bundle %s: no refund account configured", req.BundleId)
+		return &pb.BuildResult{
+			Included:        false,
+			InclusionReason: "builder cannot pay refunds",
+		}, nil
+	}
+
+	timestamp := s.blockTimestamp(req.TargetBlock)
+	if reason := outsideWindow(timestamp, req); reason != "" {
+		log.Printf("[Builder] Rejected bundle %s: %s", req.BundleId, reason)
+		return &pb.BuildResult{
+			Included:        false,
+			InclusionReason: reason,
+		}, nil
+	}
 
-	block := BuildCandidateBlock(s.pending)
+	if req.ReplacementUuid != "" {
+		s.removePending(req.Searcher, req.ReplacementUuid)
+	}
+	if s.pending == nil {
+		s.pending = make(map[uint64][]*pb.BundleSubmission)
+	}
+	s.pending[req.TargetBlock] = append(s.pending[req.TargetBlock], req)
+
+	// Build from a copy of the set, so that ranking does not reorder the
+	// pending bundles, leaving out those the block's timestamp no longer
+	// fits now that the head has moved.
+	var candidates []*pb.BundleSubmission
+	for _, bundle := range s.pending[req.TargetBlock] {
+		if outsideWindow(timestamp, bundle) == "" {
+			candidates = append(candidates, bundle)
+		}
+	}
+	block := BuildCandidateBlock(candidates)
 	ranked := RankBundles(block.Bundles)
 	selected := ranked[0]
 
-	log.Printf("[Builder] Selected bundle %s for inclusion", selected.BundleId)
+	log.Printf("[Builder] Selected bundle %s of %d for block %d", selected.BundleId, len(ranked), req.TargetBlock)
 
 	time.Sleep(300 * time.Millisecond)
 
+	reason := "Highest profit"
+	if selected != req {
+		reason = "Outbid by bundle " + selected.BundleId
+	}
+
 	result := &pb.BuildResult{
-		BlockHash:          GenerateBlockHash(selected.BundleId),
-		Included:           true,
-		InclusionReason:    "Highest profit",
-		InclusionLatencyMs: 300,
+		BlockHash:            GenerateBlockHash(selected.BundleId),
+		Included:             selected == req,
+		InclusionReason:      reason,
+		InclusionLatencyMs:   300,
+		BundleId:             selected.BundleId,
+		Txs:                  selected.Txs,
+		BlockNumber:          selected.TargetBlock,
+		GasUsed:              selected.GasUsed,
+		Value:                proposerValue(selected).String(),
+		ProposerFeeRecipient: selected.ProposerFeeRecipient,
+		ProposerPubkey:       selected.ProposerPubkey,
+		BuilderPubkey:        s.Pubkey.String(),
+	}
+	if selected.ProposerFeeRecipient != "" {
+		log.Printf("[Builder] Paying %s wei to proposer fee recipient %s", result.Value, selected.ProposerFeeRecipient)
+	}
+	if refund := Refund(selected); refund.Sign() > 0 {
+		tx, err := s.Refunds.Pay(ctx, common.HexToAddress(selected.RefundRecipient), refund)
+		if err != nil {
+			return nil, fmt.Errorf("paying refund of bundle %s: %w", selected.BundleId, err)
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("encoding refund of bundle %s: %w", selected.BundleId, err)
+		}
+
+		// The refund follows the backrun it is paid out of.
+		result.Txs = append(slices.Clone(selected.Txs), hexutil.Encode(raw))
+		result.GasUsed += tx.Gas()
+		result.RefundWei = refund.String()
+		result.RefundRecipient = selected.RefundRecipient
+		result.RefundTxHash = tx.Hash().Hex()
+		log.Printf("[Builder] Paying a refund of %s wei to %s in tx %s", result.RefundWei, result.RefundRecipient, result.RefundTxHash)
 	}
 
 	s.history = append(s.history, result)
-	s.pending = []*pb.BundleSubmission{}
 
 	if s.Publisher != nil {
-		if err := s.Publisher.Publish(result); err != nil {
+		if err := s.Publisher.Publish(ctx, result); err != nil {
 			log.Printf("failed to publish build result: %v", err)
 		}
 	}
 
 	return result, nil
 }
+
+// Prune drops the pending bundles of every block up to head, which has
+// been mined at headTime, and rejects later submissions targeting those
+// blocks.
+func (s *Service) Prune(head, headTime uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if head <= s.head {
+		return
+	}
+	s.head, s.headTime = head, headTime
+
+	dropped := 0
+	for target, bundles := range s.pending {
+		if target <= head {
+			dropped += len(bundles)
+			delete(s.pending, target)
+		}
+	}
+	if dropped > 0 {
+		log.Printf("[Builder] Pruned %d pending bundle(s) at head %d", dropped, head)
+	}
+}
+
+// blockTimestamp predicts the timestamp of the target block from the head,
+// or returns 0 while no head has been seen. Callers must hold mu.
+func (s *Service) blockTimestamp(target uint64) uint64 {
+	if s.head == 0 || target <= s.head {
+		return 0
+	}
+	return s.headTime + (target-s.head)*s.BlockTime
+}
+
+// CancelBundle drops a searcher's pending bundle before the next build.
+func (s *Service) CancelBundle(ctx context.Context, req *pb.CancelRequest) (*pb.CancelResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.MatchedTxHash != "" {
+		removed := s.removeMatched(req.MatchedTxHash)
+		log.Printf("[Builder] Cancelled %d pending bundle(s) carrying tx %s", removed, req.MatchedTxHash)
+		return &pb.CancelResult{Removed: int32(removed)}, nil
+	}
+
+	removed := s.removePending(req.Searcher, req.ReplacementUuid)
+	log.Printf("[Builder] Cancelled %d pending bundle(s) of %s (uuid=%s)", removed, req.Searcher, req.ReplacementUuid)
+
+	return &pb.CancelResult{Removed: int32(removed)}, nil
+}
+
+// removePending drops pending bundles sharing the searcher's replacement
+// UUID and returns how many were removed. Callers must hold mu.
+func (s *Service) removePending(searcher, uuid string) int {
+	return s.removeIf(func(bundle *pb.BundleSubmission) bool {
+		return bundle.Searcher == searcher && bundle.ReplacementUuid == uuid
+	})
+}
+
+// removeMatched drops pending bundles carrying the shared user transaction
+// and returns how many were removed. Callers must hold mu.
+func (s *Service) removeMatched(txHash string) int {
+	return s.removeIf(func(bundle *pb.BundleSubmission) bool {
+		return bundle.MatchedTxHash == txHash
+	})
+}
+
+// removeIf drops the pending bundles of every target block that match and
+// returns how many were removed. Callers must hold mu.
+func (s *Service) removeIf(match func(*pb.BundleSubmission) bool) int {
+	removed := 0
+	for target, bundles := range s.pending {
+		kept := bundles[:0]
+		for _, bundle := range bundles {
+			if !match(bundle) {
+				kept = append(kept, bundle)
+			}
+		}
+		removed += len(bundles) - len(kept)
+		if len(kept) == 0 {
+			delete(s.pending, target)
+		} else {
+			s.pending[target] = kept
+		}
+	}
+	return removed
+}
+
+// outsideWindow returns why a block with the given timestamp cannot include
+// the bundle, or "" if it can. A zero bound of the bundle's window is open,
+// and an unknown timestamp of zero fits every window.
+func outsideWindow(timestamp uint64, bundle *pb.BundleSubmission) string {
+	if timestamp == 0 {
+		return ""
+	}
+	if bundle.MinTimestamp > 0 && timestamp < uint64(bundle.MinTimestamp) {
+		return fmt.Sprintf("block timestamp %d is before minTimestamp %d", timestamp, bundle.MinTimestamp)
+	}
+	if bundle.MaxTimestamp > 0 && timestamp > uint64(bundle.MaxTimestamp) {
+		return fmt.Sprintf("block timestamp %d is after maxTimestamp %d", timestamp, bundle.MaxTimestamp)
+	}
+	return ""
+}
+
+// disallowedRevert returns the first reverted transaction of the bundle that
+// is not listed in its reverting transaction hashes, or "" if there is none.
+func disallowedRevert(bundle *pb.BundleSubmission) string {
+	allowed := make(map[common.Hash]bool, len(bundle.RevertingTxHashes))
+	for _, hash := range bundle.RevertingTxHashes {
+		allowed[common.HexToHash(hash)] = true
+	}
+
+	for _, hash := range bundle.RevertedTxHashes {
+		if !allowed[common.HexToHash(hash)] {
+			return hash
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"mev-relay/internal/pb"
+)
+
+func TestSubmitBundleChecksTimestampWindow(t *testing.T) {
+	const head, headTime, blockTime = 100, 1_000, 12
+	// Block 101 is expected at 1012.
+	tests := []struct {
+		name     string
+		min, max int64
+		noHead   bool
+		want     bool
+	}{
+		{"no window", 0, 0, false, true},
+		{"min at the block timestamp", 1_012, 0, false, true},
+		{"min after the block timestamp", 1_013, 0, false, false},
+		{"max at the block timestamp", 0, 1_012, false, true},
+		{"max before the block timestamp", 0, 1_011, false, false},
+		{"window around the block timestamp", 1_000, 1_100, false, true},
+		{"head not seen yet", 1_013, 0, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{BlockTime: blockTime}
+			if !tt.noHead {
+				s.Prune(head, headTime)
+			}
+
+			result, err := s.SubmitBundle(context.Background(), &pb.BundleSubmission{
+				BundleId:     "0x01",
+				CoinbaseDiff: "1000",
+				TargetBlock:  head + 1,
+				MinTimestamp: tt.min,
+				MaxTimestamp: tt.max,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Included != tt.want {
+				t.Errorf("included = %v (%s), want %v", result.Included, result.InclusionReason, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmitBundleLeavesOutExpiredPendingBundles(t *testing.T) {
+	s := &Service{BlockTime: 12}
+	s.Prune(100, 1_000)
+
+	// Valid for block 102 while it is expected at 1024.
+	expiring := &pb.BundleSubmission{BundleId: "0xa", CoinbaseDiff: "2000", TargetBlock: 102, MaxTimestamp: 1_024}
+	if result, _ := s.SubmitBundle(context.Background(), expiring); !result.Included {
+		t.Fatalf("expiring bundle not included: %s", result.InclusionReason)
+	}
+
+	// Block 101 came late, so block 102 is now expected at 1032.
+	s.Prune(101, 1_020)
+	result, _ := s.SubmitBundle(context.Background(), &pb.BundleSubmission{BundleId: "0xb", CoinbaseDiff: "1000", TargetBlock: 102})
+	if !result.Included || result.BundleId != "0xb" {
+		t.Errorf("built from %s (%s), want the bundle still in its window", result.BundleId, result.InclusionReason)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/internal/tracing"
 )
 
 func NewPool(databaseURL string) *pgxpool.Pool {
@@ -16,6 +17,7 @@ func NewPool(databaseURL string) *pgxpool.Pool {
 	if err != nil {
 		log.Fatal("Failed to parse database config:", err)
 	}
+	cfg.ConnConfig.Tracer = tracing.DBTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
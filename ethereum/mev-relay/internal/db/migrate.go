@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/scripts"
+)
+
+// migrationLock is the advisory lock key serializing migrations of services
+// starting at the same time.
+const migrationLock = 0x6d6576
+
+// Migrate applies the schema to the database. The schema is idempotent, so
+// it brings a database created by an older version up to date and leaves a
+// current one unchanged.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return fmt.Errorf("taking migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock)
+
+	// Without arguments the statements are sent in one simple query.
+	if _, err := conn.Exec(ctx, scripts.Schema); err != nil {
+		return fmt.Errorf("applying schema: %w", err)
+	}
+	log.Println("[DB] Schema is up to date")
+	return nil
+}
@@ -22,12 +22,27 @@ const (
 )
 
 type BundleSubmission struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	BundleId      string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
-	ProfitEth     float64                `protobuf:"fixed64,2,opt,name=profit_eth,json=profitEth,proto3" json:"profit_eth,omitempty"`
-	Txs           []string               `protobuf:"bytes,3,rep,name=txs,proto3" json:"txs,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	BundleId             string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
+	ProfitEth            float64                `protobuf:"fixed64,2,opt,name=profit_eth,json=profitEth,proto3" json:"profit_eth,omitempty"`
+	Txs                  []string               `protobuf:"bytes,3,rep,name=txs,proto3" json:"txs,omitempty"`
+	CoinbaseDiff         string                 `protobuf:"bytes,4,opt,name=coinbase_diff,json=coinbaseDiff,proto3" json:"coinbase_diff,omitempty"` // wei, decimal string
+	Searcher             string                 `protobuf:"bytes,5,opt,name=searcher,proto3" json:"searcher,omitempty"`
+	ReplacementUuid      string                 `protobuf:"bytes,6,opt,name=replacement_uuid,json=replacementUuid,proto3" json:"replacement_uuid,omitempty"`
+	RevertingTxHashes    []string               `protobuf:"bytes,7,rep,name=reverting_tx_hashes,json=revertingTxHashes,proto3" json:"reverting_tx_hashes,omitempty"` // transactions allowed to revert
+	RevertedTxHashes     []string               `protobuf:"bytes,8,rep,name=reverted_tx_hashes,json=revertedTxHashes,proto3" json:"reverted_tx_hashes,omitempty"`    // transactions that reverted in simulation
+	MatchedTxHash        string                 `protobuf:"bytes,9,opt,name=matched_tx_hash,json=matchedTxHash,proto3" json:"matched_tx_hash,omitempty"`             // user transaction shared through the order flow auction
+	BackrunValue         string                 `protobuf:"bytes,10,opt,name=backrun_value,json=backrunValue,proto3" json:"backrun_value,omitempty"`                 // wei paid to the coinbase by the backrun, decimal string
+	RefundPercent        int32                  `protobuf:"varint,11,opt,name=refund_percent,json=refundPercent,proto3" json:"refund_percent,omitempty"`             // share of the backrun value refunded to the user
+	RefundRecipient      string                 `protobuf:"bytes,12,opt,name=refund_recipient,json=refundRecipient,proto3" json:"refund_recipient,omitempty"`
+	TargetBlock          uint64                 `protobuf:"varint,13,opt,name=target_block,json=targetBlock,proto3" json:"target_block,omitempty"`
+	GasUsed              uint64                 `protobuf:"varint,14,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	ProposerFeeRecipient string                 `protobuf:"bytes,15,opt,name=proposer_fee_recipient,json=proposerFeeRecipient,proto3" json:"proposer_fee_recipient,omitempty"` // registered fee recipient of the target block's proposer
+	ProposerPubkey       string                 `protobuf:"bytes,16,opt,name=proposer_pubkey,json=proposerPubkey,proto3" json:"proposer_pubkey,omitempty"`
+	MinTimestamp         int64                  `protobuf:"varint,17,opt,name=min_timestamp,json=minTimestamp,proto3" json:"min_timestamp,omitempty"` // earliest block timestamp the bundle is valid in, 0 for none
+	MaxTimestamp         int64                  `protobuf:"varint,18,opt,name=max_timestamp,json=maxTimestamp,proto3" json:"max_timestamp,omitempty"` // latest block timestamp the bundle is valid in, 0 for none
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *BundleSubmission) Reset() {
@@ -81,19 +96,242 @@ func (x *BundleSubmission) GetTxs() []string {
 	return nil
 }
 
+func (x *BundleSubmission) GetCoinbaseDiff() string {
+	if x != nil {
+		return x.CoinbaseDiff
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetSearcher() string {
+	if x != nil {
+		return x.Searcher
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetReplacementUuid() string {
+	if x != nil {
+		return x.ReplacementUuid
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetRevertingTxHashes() []string {
+	if x != nil {
+		return x.RevertingTxHashes
+	}
+	return nil
+}
+
+func (x *BundleSubmission) GetRevertedTxHashes() []string {
+	if x != nil {
+		return x.RevertedTxHashes
+	}
+	return nil
+}
+
+func (x *BundleSubmission) GetMatchedTxHash() string {
+	if x != nil {
+		return x.MatchedTxHash
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetBackrunValue() string {
+	if x != nil {
+		return x.BackrunValue
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetRefundPercent() int32 {
+	if x != nil {
+		return x.RefundPercent
+	}
+	return 0
+}
+
+func (x *BundleSubmission) GetRefundRecipient() string {
+	if x != nil {
+		return x.RefundRecipient
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetTargetBlock() uint64 {
+	if x != nil {
+		return x.TargetBlock
+	}
+	return 0
+}
+
+func (x *BundleSubmission) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *BundleSubmission) GetProposerFeeRecipient() string {
+	if x != nil {
+		return x.ProposerFeeRecipient
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetProposerPubkey() string {
+	if x != nil {
+		return x.ProposerPubkey
+	}
+	return ""
+}
+
+func (x *BundleSubmission) GetMinTimestamp() int64 {
+	if x != nil {
+		return x.MinTimestamp
+	}
+	return 0
+}
+
+func (x *BundleSubmission) GetMaxTimestamp() int64 {
+	if x != nil {
+		return x.MaxTimestamp
+	}
+	return 0
+}
+
+// CancelRequest removes a searcher's pending bundle by replacement UUID, or
+// every pending bundle carrying a shared user transaction.
+type CancelRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Searcher        string                 `protobuf:"bytes,1,opt,name=searcher,proto3" json:"searcher,omitempty"`
+	ReplacementUuid string                 `protobuf:"bytes,2,opt,name=replacement_uuid,json=replacementUuid,proto3" json:"replacement_uuid,omitempty"`
+	MatchedTxHash   string                 `protobuf:"bytes,3,opt,name=matched_tx_hash,json=matchedTxHash,proto3" json:"matched_tx_hash,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_proto_builder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_builder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_proto_builder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CancelRequest) GetSearcher() string {
+	if x != nil {
+		return x.Searcher
+	}
+	return ""
+}
+
+func (x *CancelRequest) GetReplacementUuid() string {
+	if x != nil {
+		return x.ReplacementUuid
+	}
+	return ""
+}
+
+func (x *CancelRequest) GetMatchedTxHash() string {
+	if x != nil {
+		return x.MatchedTxHash
+	}
+	return ""
+}
+
+type CancelResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Removed       int32                  `protobuf:"varint,1,opt,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResult) Reset() {
+	*x = CancelResult{}
+	mi := &file_proto_builder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResult) ProtoMessage() {}
+
+func (x *CancelResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_builder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResult.ProtoReflect.Descriptor instead.
+func (*CancelResult) Descriptor() ([]byte, []int) {
+	return file_proto_builder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CancelResult) GetRemoved() int32 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
 type BuildResult struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	BlockHash          string                 `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
 	Included           bool                   `protobuf:"varint,2,opt,name=included,proto3" json:"included,omitempty"`
 	InclusionReason    string                 `protobuf:"bytes,3,opt,name=inclusion_reason,json=inclusionReason,proto3" json:"inclusion_reason,omitempty"`
 	InclusionLatencyMs int64                  `protobuf:"varint,4,opt,name=inclusion_latency_ms,json=inclusionLatencyMs,proto3" json:"inclusion_latency_ms,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+	RefundWei          string                 `protobuf:"bytes,5,opt,name=refund_wei,json=refundWei,proto3" json:"refund_wei,omitempty"` // decimal string
+	RefundRecipient    string                 `protobuf:"bytes,6,opt,name=refund_recipient,json=refundRecipient,proto3" json:"refund_recipient,omitempty"`
+	// The selected bundle the block was built from.
+	BundleId             string   `protobuf:"bytes,7,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
+	Txs                  []string `protobuf:"bytes,8,rep,name=txs,proto3" json:"txs,omitempty"`
+	BlockNumber          uint64   `protobuf:"varint,9,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	GasUsed              uint64   `protobuf:"varint,10,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	Value                string   `protobuf:"bytes,11,opt,name=value,proto3" json:"value,omitempty"`                                                             // wei paid to the proposer, decimal string
+	ProposerFeeRecipient string   `protobuf:"bytes,12,opt,name=proposer_fee_recipient,json=proposerFeeRecipient,proto3" json:"proposer_fee_recipient,omitempty"` // address the proposer payment is made to
+	ProposerPubkey       string   `protobuf:"bytes,13,opt,name=proposer_pubkey,json=proposerPubkey,proto3" json:"proposer_pubkey,omitempty"`
+	BuilderPubkey        string   `protobuf:"bytes,14,opt,name=builder_pubkey,json=builderPubkey,proto3" json:"builder_pubkey,omitempty"` // BLS public key identifying the builder
+	RefundTxHash         string   `protobuf:"bytes,15,opt,name=refund_tx_hash,json=refundTxHash,proto3" json:"refund_tx_hash,omitempty"`  // transaction paying the refund to the user
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *BuildResult) Reset() {
 	*x = BuildResult{}
-	mi := &file_proto_builder_proto_msgTypes[1]
+	mi := &file_proto_builder_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -105,7 +343,7 @@ func (x *BuildResult) String() string {
 func (*BuildResult) ProtoMessage() {}
 
 func (x *BuildResult) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_builder_proto_msgTypes[1]
+	mi := &file_proto_builder_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,7 +356,7 @@ func (x *BuildResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildResult.ProtoReflect.Descriptor instead.
 func (*BuildResult) Descriptor() ([]byte, []int) {
-	return file_proto_builder_proto_rawDescGZIP(), []int{1}
+	return file_proto_builder_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *BuildResult) GetBlockHash() string {
@@ -149,24 +387,137 @@ func (x *BuildResult) GetInclusionLatencyMs() int64 {
 	return 0
 }
 
+func (x *BuildResult) GetRefundWei() string {
+	if x != nil {
+		return x.RefundWei
+	}
+	return ""
+}
+
+func (x *BuildResult) GetRefundRecipient() string {
+	if x != nil {
+		return x.RefundRecipient
+	}
+	return ""
+}
+
+func (x *BuildResult) GetBundleId() string {
+	if x != nil {
+		return x.BundleId
+	}
+	return ""
+}
+
+func (x *BuildResult) GetTxs() []string {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+func (x *BuildResult) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *BuildResult) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *BuildResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BuildResult) GetProposerFeeRecipient() string {
+	if x != nil {
+		return x.ProposerFeeRecipient
+	}
+	return ""
+}
+
+func (x *BuildResult) GetProposerPubkey() string {
+	if x != nil {
+		return x.ProposerPubkey
+	}
+	return ""
+}
+
+func (x *BuildResult) GetBuilderPubkey() string {
+	if x != nil {
+		return x.BuilderPubkey
+	}
+	return ""
+}
+
+func (x *BuildResult) GetRefundTxHash() string {
+	if x != nil {
+		return x.RefundTxHash
+	}
+	return ""
+}
+
 var File_proto_builder_proto protoreflect.FileDescriptor
 
 const file_proto_builder_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/builder.proto\x12\abuilder\"`\n" +
+	"\x13proto/builder.proto\x12\abuilder\"\xb0\x05\n" +
 	"\x10BundleSubmission\x12\x1b\n" +
 	"\tbundle_id\x18\x01 \x01(\tR\bbundleId\x12\x1d\n" +
 	"\n" +
 	"profit_eth\x18\x02 \x01(\x01R\tprofitEth\x12\x10\n" +
-	"\x03txs\x18\x03 \x03(\tR\x03txs\"\xa5\x01\n" +
+	"\x03txs\x18\x03 \x03(\tR\x03txs\x12#\n" +
+	"\rcoinbase_diff\x18\x04 \x01(\tR\fcoinbaseDiff\x12\x1a\n" +
+	"\bsearcher\x18\x05 \x01(\tR\bsearcher\x12)\n" +
+	"\x10replacement_uuid\x18\x06 \x01(\tR\x0freplacementUuid\x12.\n" +
+	"\x13reverting_tx_hashes\x18\a \x03(\tR\x11revertingTxHashes\x12,\n" +
+	"\x12reverted_tx_hashes\x18\b \x03(\tR\x10revertedTxHashes\x12&\n" +
+	"\x0fmatched_tx_hash\x18\t \x01(\tR\rmatchedTxHash\x12#\n" +
+	"\rbackrun_value\x18\n" +
+	" \x01(\tR\fbackrunValue\x12%\n" +
+	"\x0erefund_percent\x18\v \x01(\x05R\rrefundPercent\x12)\n" +
+	"\x10refund_recipient\x18\f \x01(\tR\x0frefundRecipient\x12!\n" +
+	"\ftarget_block\x18\r \x01(\x04R\vtargetBlock\x12\x19\n" +
+	"\bgas_used\x18\x0e \x01(\x04R\agasUsed\x124\n" +
+	"\x16proposer_fee_recipient\x18\x0f \x01(\tR\x14proposerFeeRecipient\x12'\n" +
+	"\x0fproposer_pubkey\x18\x10 \x01(\tR\x0eproposerPubkey\x12#\n" +
+	"\rmin_timestamp\x18\x11 \x01(\x03R\fminTimestamp\x12#\n" +
+	"\rmax_timestamp\x18\x12 \x01(\x03R\fmaxTimestamp\"~\n" +
+	"\rCancelRequest\x12\x1a\n" +
+	"\bsearcher\x18\x01 \x01(\tR\bsearcher\x12)\n" +
+	"\x10replacement_uuid\x18\x02 \x01(\tR\x0freplacementUuid\x12&\n" +
+	"\x0fmatched_tx_hash\x18\x03 \x01(\tR\rmatchedTxHash\"(\n" +
+	"\fCancelResult\x12\x18\n" +
+	"\aremoved\x18\x01 \x01(\x05R\aremoved\"\x9e\x04\n" +
 	"\vBuildResult\x12\x1d\n" +
 	"\n" +
 	"block_hash\x18\x01 \x01(\tR\tblockHash\x12\x1a\n" +
 	"\bincluded\x18\x02 \x01(\bR\bincluded\x12)\n" +
 	"\x10inclusion_reason\x18\x03 \x01(\tR\x0finclusionReason\x120\n" +
-	"\x14inclusion_latency_ms\x18\x04 \x01(\x03R\x12inclusionLatencyMs2Q\n" +
+	"\x14inclusion_latency_ms\x18\x04 \x01(\x03R\x12inclusionLatencyMs\x12\x1d\n" +
+	"\n" +
+	"refund_wei\x18\x05 \x01(\tR\trefundWei\x12)\n" +
+	"\x10refund_recipient\x18\x06 \x01(\tR\x0frefundRecipient\x12\x1b\n" +
+	"\tbundle_id\x18\a \x01(\tR\bbundleId\x12\x10\n" +
+	"\x03txs\x18\b \x03(\tR\x03txs\x12!\n" +
+	"\fblock_number\x18\t \x01(\x04R\vblockNumber\x12\x19\n" +
+	"\bgas_used\x18\n" +
+	" \x01(\x04R\agasUsed\x12\x14\n" +
+	"\x05value\x18\v \x01(\tR\x05value\x124\n" +
+	"\x16proposer_fee_recipient\x18\f \x01(\tR\x14proposerFeeRecipient\x12'\n" +
+	"\x0fproposer_pubkey\x18\r \x01(\tR\x0eproposerPubkey\x12%\n" +
+	"\x0ebuilder_pubkey\x18\x0e \x01(\tR\rbuilderPubkey\x12$\n" +
+	"\x0erefund_tx_hash\x18\x0f \x01(\tR\frefundTxHash2\x90\x01\n" +
 	"\x0eBuilderService\x12?\n" +
-	"\fSubmitBundle\x12\x19.builder.BundleSubmission\x1a\x14.builder.BuildResultB\x17Z\x15mev-relay/internal/pbb\x06proto3"
+	"\fSubmitBundle\x12\x19.builder.BundleSubmission\x1a\x14.builder.BuildResult\x12=\n" +
+	"\fCancelBundle\x12\x16.builder.CancelRequest\x1a\x15.builder.CancelResultB\x17Z\x15mev-relay/internal/pbb\x06proto3"
 
 var (
 	file_proto_builder_proto_rawDescOnce sync.Once
@@ -180,16 +531,20 @@ func file_proto_builder_proto_rawDescGZIP() []byte {
 	return file_proto_builder_proto_rawDescData
 }
 
-var file_proto_builder_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_builder_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_builder_proto_goTypes = []any{
 	(*BundleSubmission)(nil), // 0: builder.BundleSubmission
-	(*BuildResult)(nil),      // 1: builder.BuildResult
+	(*CancelRequest)(nil),    // 1: builder.CancelRequest
+	(*CancelResult)(nil),     // 2: builder.CancelResult
+	(*BuildResult)(nil),      // 3: builder.BuildResult
 }
 var file_proto_builder_proto_depIdxs = []int32{
 	0, // 0: builder.BuilderService.SubmitBundle:input_type -> builder.BundleSubmission
-	1, // 1: builder.BuilderService.SubmitBundle:output_type -> builder.BuildResult
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	1, // 1: builder.BuilderService.CancelBundle:input_type -> builder.CancelRequest
+	3, // 2: builder.BuilderService.SubmitBundle:output_type -> builder.BuildResult
+	2, // 3: builder.BuilderService.CancelBundle:output_type -> builder.CancelResult
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -206,7 +561,7 @@ func file_proto_builder_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_builder_proto_rawDesc), len(file_proto_builder_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	BuilderService_SubmitBundle_FullMethodName = "/builder.BuilderService/SubmitBundle"
+	BuilderService_CancelBundle_FullMethodName = "/builder.BuilderService/CancelBundle"
 )
 
 // BuilderServiceClient is the client API for BuilderService service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BuilderServiceClient interface {
 	SubmitBundle(ctx context.Context, in *BundleSubmission, opts ...grpc.CallOption) (*BuildResult, error)
+	CancelBundle(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResult, error)
 }
 
 type builderServiceClient struct {
@@ -47,11 +49,22 @@ func (c *builderServiceClient) SubmitBundle(ctx context.Context, in *BundleSubmi
 	return out, nil
 }
 
+func (c *builderServiceClient) CancelBundle(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelResult)
+	err := c.cc.Invoke(ctx, BuilderService_CancelBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BuilderServiceServer is the server API for BuilderService service.
 // All implementations must embed UnimplementedBuilderServiceServer
 // for forward compatibility.
 type BuilderServiceServer interface {
 	SubmitBundle(context.Context, *BundleSubmission) (*BuildResult, error)
+	CancelBundle(context.Context, *CancelRequest) (*CancelResult, error)
 	mustEmbedUnimplementedBuilderServiceServer()
 }
 
@@ -65,6 +78,9 @@ type UnimplementedBuilderServiceServer struct{}
 func (UnimplementedBuilderServiceServer) SubmitBundle(context.Context, *BundleSubmission) (*BuildResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SubmitBundle not implemented")
 }
+func (UnimplementedBuilderServiceServer) CancelBundle(context.Context, *CancelRequest) (*CancelResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelBundle not implemented")
+}
 func (UnimplementedBuilderServiceServer) mustEmbedUnimplementedBuilderServiceServer() {}
 func (UnimplementedBuilderServiceServer) testEmbeddedByValue()                        {}
 
@@ -104,6 +120,24 @@ func _BuilderService_SubmitBundle_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BuilderService_CancelBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuilderServiceServer).CancelBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BuilderService_CancelBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuilderServiceServer).CancelBundle(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BuilderService_ServiceDesc is the grpc.ServiceDesc for BuilderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +149,10 @@ var BuilderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SubmitBundle",
 			Handler:    _BuilderService_SubmitBundle_Handler,
 		},
+		{
+			MethodName: "CancelBundle",
+			Handler:    _BuilderService_CancelBundle_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/builder.proto",
@@ -22,12 +22,18 @@ const (
 )
 
 type BundleRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	BundleId      string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
-	Txs           []string               `protobuf:"bytes,2,rep,name=txs,proto3" json:"txs,omitempty"`
-	TargetBlock   string                 `protobuf:"bytes,3,opt,name=target_block,json=targetBlock,proto3" json:"target_block,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	BundleId          string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
+	Txs               []string               `protobuf:"bytes,2,rep,name=txs,proto3" json:"txs,omitempty"`
+	TargetBlock       string                 `protobuf:"bytes,3,opt,name=target_block,json=targetBlock,proto3" json:"target_block,omitempty"`
+	Searcher          string                 `protobuf:"bytes,4,opt,name=searcher,proto3" json:"searcher,omitempty"`
+	StateBlock        string                 `protobuf:"bytes,5,opt,name=state_block,json=stateBlock,proto3" json:"state_block,omitempty"`                        // "latest" or a block number; empty means latest
+	Timestamp         int64                  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                           // block timestamp override, 0 keeps the node's clock
+	RevertingTxHashes []string               `protobuf:"bytes,7,rep,name=reverting_tx_hashes,json=revertingTxHashes,proto3" json:"reverting_tx_hashes,omitempty"` // transactions allowed to revert
+	MinTimestamp      int64                  `protobuf:"varint,8,opt,name=min_timestamp,json=minTimestamp,proto3" json:"min_timestamp,omitempty"`                 // earliest block timestamp the bundle is valid in, 0 for none
+	MaxTimestamp      int64                  `protobuf:"varint,9,opt,name=max_timestamp,json=maxTimestamp,proto3" json:"max_timestamp,omitempty"`                 // latest block timestamp the bundle is valid in, 0 for none
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *BundleRequest) Reset() {
@@ -81,15 +87,64 @@ func (x *BundleRequest) GetTargetBlock() string {
 	return ""
 }
 
+func (x *BundleRequest) GetSearcher() string {
+	if x != nil {
+		return x.Searcher
+	}
+	return ""
+}
+
+func (x *BundleRequest) GetStateBlock() string {
+	if x != nil {
+		return x.StateBlock
+	}
+	return ""
+}
+
+func (x *BundleRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *BundleRequest) GetRevertingTxHashes() []string {
+	if x != nil {
+		return x.RevertingTxHashes
+	}
+	return nil
+}
+
+func (x *BundleRequest) GetMinTimestamp() int64 {
+	if x != nil {
+		return x.MinTimestamp
+	}
+	return 0
+}
+
+func (x *BundleRequest) GetMaxTimestamp() int64 {
+	if x != nil {
+		return x.MaxTimestamp
+	}
+	return 0
+}
+
 type BundleResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	BundleId      string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
-	ProfitEth     float64                `protobuf:"fixed64,2,opt,name=profit_eth,json=profitEth,proto3" json:"profit_eth,omitempty"`
-	LatencyMs     int64                  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
-	Success       bool                   `protobuf:"varint,4,opt,name=success,proto3" json:"success,omitempty"`
-	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	BundleId  string                 `protobuf:"bytes,1,opt,name=bundle_id,json=bundleId,proto3" json:"bundle_id,omitempty"`
+	ProfitEth float64                `protobuf:"fixed64,2,opt,name=profit_eth,json=profitEth,proto3" json:"profit_eth,omitempty"`
+	LatencyMs int64                  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	Success   bool                   `protobuf:"varint,4,opt,name=success,proto3" json:"success,omitempty"`
+	Reason    string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Proposer payment in wei, as decimal strings.
+	CoinbaseDiff      string      `protobuf:"bytes,6,opt,name=coinbase_diff,json=coinbaseDiff,proto3" json:"coinbase_diff,omitempty"`
+	GasFees           string      `protobuf:"bytes,7,opt,name=gas_fees,json=gasFees,proto3" json:"gas_fees,omitempty"`
+	EthSentToCoinbase string      `protobuf:"bytes,8,opt,name=eth_sent_to_coinbase,json=ethSentToCoinbase,proto3" json:"eth_sent_to_coinbase,omitempty"`
+	Results           []*TxResult `protobuf:"bytes,9,rep,name=results,proto3" json:"results,omitempty"`
+	GasUsed           uint64      `protobuf:"varint,10,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	StateBlock        uint64      `protobuf:"varint,11,opt,name=state_block,json=stateBlock,proto3" json:"state_block,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *BundleResponse) Reset() {
@@ -157,15 +212,233 @@ func (x *BundleResponse) GetReason() string {
 	return ""
 }
 
+func (x *BundleResponse) GetCoinbaseDiff() string {
+	if x != nil {
+		return x.CoinbaseDiff
+	}
+	return ""
+}
+
+func (x *BundleResponse) GetGasFees() string {
+	if x != nil {
+		return x.GasFees
+	}
+	return ""
+}
+
+func (x *BundleResponse) GetEthSentToCoinbase() string {
+	if x != nil {
+		return x.EthSentToCoinbase
+	}
+	return ""
+}
+
+func (x *BundleResponse) GetResults() []*TxResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BundleResponse) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *BundleResponse) GetStateBlock() uint64 {
+	if x != nil {
+		return x.StateBlock
+	}
+	return 0
+}
+
+// TxResult is the outcome of one transaction of the bundle, in bundle order.
+type TxResult struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	TxHash            string                 `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	GasUsed           uint64                 `protobuf:"varint,2,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	Success           bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	RevertReason      string                 `protobuf:"bytes,4,opt,name=revert_reason,json=revertReason,proto3" json:"revert_reason,omitempty"`
+	Logs              []*Log                 `protobuf:"bytes,5,rep,name=logs,proto3" json:"logs,omitempty"`
+	CoinbaseDiff      string                 `protobuf:"bytes,6,opt,name=coinbase_diff,json=coinbaseDiff,proto3" json:"coinbase_diff,omitempty"`
+	GasFees           string                 `protobuf:"bytes,7,opt,name=gas_fees,json=gasFees,proto3" json:"gas_fees,omitempty"`
+	EthSentToCoinbase string                 `protobuf:"bytes,8,opt,name=eth_sent_to_coinbase,json=ethSentToCoinbase,proto3" json:"eth_sent_to_coinbase,omitempty"`
+	Error             string                 `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"` // set when the node rejected the transaction outright
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TxResult) Reset() {
+	*x = TxResult{}
+	mi := &file_proto_simulator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxResult) ProtoMessage() {}
+
+func (x *TxResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_simulator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxResult.ProtoReflect.Descriptor instead.
+func (*TxResult) Descriptor() ([]byte, []int) {
+	return file_proto_simulator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TxResult) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *TxResult) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *TxResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *TxResult) GetRevertReason() string {
+	if x != nil {
+		return x.RevertReason
+	}
+	return ""
+}
+
+func (x *TxResult) GetLogs() []*Log {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *TxResult) GetCoinbaseDiff() string {
+	if x != nil {
+		return x.CoinbaseDiff
+	}
+	return ""
+}
+
+func (x *TxResult) GetGasFees() string {
+	if x != nil {
+		return x.GasFees
+	}
+	return ""
+}
+
+func (x *TxResult) GetEthSentToCoinbase() string {
+	if x != nil {
+		return x.EthSentToCoinbase
+	}
+	return ""
+}
+
+func (x *TxResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type Log struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Topics        []string               `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Log) Reset() {
+	*x = Log{}
+	mi := &file_proto_simulator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Log) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Log) ProtoMessage() {}
+
+func (x *Log) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_simulator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Log.ProtoReflect.Descriptor instead.
+func (*Log) Descriptor() ([]byte, []int) {
+	return file_proto_simulator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Log) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Log) GetTopics() []string {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+func (x *Log) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
 var File_proto_simulator_proto protoreflect.FileDescriptor
 
 const file_proto_simulator_proto_rawDesc = "" +
 	"\n" +
-	"\x15proto/simulator.proto\x12\tsimulator\"a\n" +
+	"\x15proto/simulator.proto\x12\tsimulator\"\xb6\x02\n" +
 	"\rBundleRequest\x12\x1b\n" +
 	"\tbundle_id\x18\x01 \x01(\tR\bbundleId\x12\x10\n" +
 	"\x03txs\x18\x02 \x03(\tR\x03txs\x12!\n" +
-	"\ftarget_block\x18\x03 \x01(\tR\vtargetBlock\"\x9d\x01\n" +
+	"\ftarget_block\x18\x03 \x01(\tR\vtargetBlock\x12\x1a\n" +
+	"\bsearcher\x18\x04 \x01(\tR\bsearcher\x12\x1f\n" +
+	"\vstate_block\x18\x05 \x01(\tR\n" +
+	"stateBlock\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x12.\n" +
+	"\x13reverting_tx_hashes\x18\a \x03(\tR\x11revertingTxHashes\x12#\n" +
+	"\rmin_timestamp\x18\b \x01(\x03R\fminTimestamp\x12#\n" +
+	"\rmax_timestamp\x18\t \x01(\x03R\fmaxTimestamp\"\xf9\x02\n" +
 	"\x0eBundleResponse\x12\x1b\n" +
 	"\tbundle_id\x18\x01 \x01(\tR\bbundleId\x12\x1d\n" +
 	"\n" +
@@ -173,7 +446,29 @@ const file_proto_simulator_proto_rawDesc = "" +
 	"\n" +
 	"latency_ms\x18\x03 \x01(\x03R\tlatencyMs\x12\x18\n" +
 	"\asuccess\x18\x04 \x01(\bR\asuccess\x12\x16\n" +
-	"\x06reason\x18\x05 \x01(\tR\x06reason2Z\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12#\n" +
+	"\rcoinbase_diff\x18\x06 \x01(\tR\fcoinbaseDiff\x12\x19\n" +
+	"\bgas_fees\x18\a \x01(\tR\agasFees\x12/\n" +
+	"\x14eth_sent_to_coinbase\x18\b \x01(\tR\x11ethSentToCoinbase\x12-\n" +
+	"\aresults\x18\t \x03(\v2\x13.simulator.TxResultR\aresults\x12\x19\n" +
+	"\bgas_used\x18\n" +
+	" \x01(\x04R\agasUsed\x12\x1f\n" +
+	"\vstate_block\x18\v \x01(\x04R\n" +
+	"stateBlock\"\xa8\x02\n" +
+	"\bTxResult\x12\x17\n" +
+	"\atx_hash\x18\x01 \x01(\tR\x06txHash\x12\x19\n" +
+	"\bgas_used\x18\x02 \x01(\x04R\agasUsed\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x12#\n" +
+	"\rrevert_reason\x18\x04 \x01(\tR\frevertReason\x12\"\n" +
+	"\x04logs\x18\x05 \x03(\v2\x0e.simulator.LogR\x04logs\x12#\n" +
+	"\rcoinbase_diff\x18\x06 \x01(\tR\fcoinbaseDiff\x12\x19\n" +
+	"\bgas_fees\x18\a \x01(\tR\agasFees\x12/\n" +
+	"\x14eth_sent_to_coinbase\x18\b \x01(\tR\x11ethSentToCoinbase\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\"K\n" +
+	"\x03Log\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06topics\x18\x02 \x03(\tR\x06topics\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\tR\x04data2Z\n" +
 	"\x11SimulationService\x12E\n" +
 	"\x0eSimulateBundle\x12\x18.simulator.BundleRequest\x1a\x19.simulator.BundleResponseB\x17Z\x15mev-relay/internal/pbb\x06proto3"
 
@@ -189,19 +484,23 @@ func file_proto_simulator_proto_rawDescGZIP() []byte {
 	return file_proto_simulator_proto_rawDescData
 }
 
-var file_proto_simulator_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_simulator_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_simulator_proto_goTypes = []any{
 	(*BundleRequest)(nil),  // 0: simulator.BundleRequest
 	(*BundleResponse)(nil), // 1: simulator.BundleResponse
+	(*TxResult)(nil),       // 2: simulator.TxResult
+	(*Log)(nil),            // 3: simulator.Log
 }
 var file_proto_simulator_proto_depIdxs = []int32{
-	0, // 0: simulator.SimulationService.SimulateBundle:input_type -> simulator.BundleRequest
-	1, // 1: simulator.SimulationService.SimulateBundle:output_type -> simulator.BundleResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: simulator.BundleResponse.results:type_name -> simulator.TxResult
+	3, // 1: simulator.TxResult.logs:type_name -> simulator.Log
+	0, // 2: simulator.SimulationService.SimulateBundle:input_type -> simulator.BundleRequest
+	1, // 3: simulator.SimulationService.SimulateBundle:output_type -> simulator.BundleResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proto_simulator_proto_init() }
@@ -215,7 +514,7 @@ func file_proto_simulator_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_simulator_proto_rawDesc), len(file_proto_simulator_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
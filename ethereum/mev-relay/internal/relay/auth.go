@@ -0,0 +1,154 @@
+package relay
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	signatureHeader = "X-Flashbots-Signature"
+	timestampHeader = "X-Flashbots-Timestamp"
+	searcherKey     = "searcher"
+
+	// maxBodyBytes bounds the body read to check a signature, before the
+	// searcher is known and rate limited.
+	maxBodyBytes = 1 << 20
+	// maxSignatureAge is how far the timestamp of a signed GET request may
+	// be from the relay's clock.
+	maxSignatureAge = 30 * time.Second
+)
+
+// searcherAuth verifies the X-Flashbots-Signature header and stores the
+// recovered searcher address in the request context. The header has the form
+// <address>:<signature>, where the signature is an EIP-191 personal signature
+// over the hex-encoded keccak256 hash of the signed payload: the request body,
+// or for GET requests the string "GET <path and query> <timestamp>", where
+// timestamp is the Unix time also sent in the X-Flashbots-Timestamp header.
+func searcherAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeRPCError(c, nullID, newRPCError(errCodeInvalidRequest, "body exceeds %d bytes", maxBodyBytes))
+			} else {
+				writeRPCError(c, nullID, newRPCError(errCodeParse, "unreadable body"))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		payload := body
+		if c.Request.Method == http.MethodGet {
+			payload, err = signedGET(c.Request, time.Now())
+			if err != nil {
+				writeRPCError(c, nullID, newRPCError(errCodeUnauthorized, "%v", err))
+				c.Abort()
+				return
+			}
+		}
+
+		searcher, err := verifySignature(c.GetHeader(signatureHeader), payload)
+		if err != nil {
+			writeRPCError(c, nullID, newRPCError(errCodeUnauthorized, "%v", err))
+			c.Abort()
+			return
+		}
+
+		c.Set(searcherKey, searcher)
+		c.Next()
+	}
+}
+
+// signedGET returns the payload a GET request is signed over. Binding the
+// signature to the path and a recent timestamp keeps a leaked header from
+// being replayed against other resources, or for longer than
+// maxSignatureAge.
+func signedGET(r *http.Request, now time.Time) ([]byte, error) {
+	header := r.Header.Get(timestampHeader)
+	if header == "" {
+		return nil, errors.New("missing " + timestampHeader + " header")
+	}
+	timestamp, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		return nil, errors.New("malformed " + timestampHeader + " header")
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return nil, fmt.Errorf("%s is more than %v away from the relay's clock", timestampHeader, maxSignatureAge)
+	}
+	return []byte(fmt.Sprintf("%s %s %d", r.Method, r.URL.RequestURI(), timestamp)), nil
+}
+
+// verifySignature returns the address that signed body, provided it matches
+// the address claimed in the header.
+func verifySignature(header string, body []byte) (common.Address, error) {
+	if header == "" {
+		return common.Address{}, errors.New("missing " + signatureHeader + " header")
+	}
+
+	addrHex, sigHex, ok := strings.Cut(header, ":")
+	if !ok || !common.IsHexAddress(addrHex) {
+		return common.Address{}, errors.New("malformed " + signatureHeader + " header")
+	}
+
+	sig, err := hexutil.Decode(sigHex)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("malformed signature")
+	}
+	// Accept both 0/1 and 27/28 recovery IDs.
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	digest := accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex()))
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, errors.New("invalid signature")
+	}
+
+	signer := crypto.PubkeyToAddress(*pub)
+	if signer != common.HexToAddress(addrHex) {
+		return common.Address{}, errors.New("signature does not match address")
+	}
+	return signer, nil
+}
+
+// adminAuth guards the admin API with the bearer token configured in
+// ADMIN_TOKEN. The admin API is disabled when no token is configured.
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API disabled: ADMIN_TOKEN is not set"})
+			return
+		}
+
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// searcherFrom returns the authenticated searcher of the request.
+func searcherFrom(c *gin.Context) common.Address {
+	searcher, _ := c.Get(searcherKey)
+	addr, _ := searcher.(common.Address)
+	return addr
+}
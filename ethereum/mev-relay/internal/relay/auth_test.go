@@ -0,0 +1,110 @@
+package relay
+
+import (
+	"crypto/ecdsa"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// signHeader returns the X-Flashbots-Signature of payload by key, with the
+// recovery ID offset by v.
+func signHeader(t *testing.T, key *ecdsa.PrivateKey, payload string, v byte) string {
+	t.Helper()
+	digest := accounts.TextHash([]byte(crypto.Keccak256Hash([]byte(payload)).Hex()))
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += v
+	return crypto.PubkeyToAddress(key.PublicKey).Hex() + ":" + hexutil.Encode(sig)
+}
+
+func TestSearcherAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	searcher := crypto.PubkeyToAddress(key.PublicKey)
+
+	router := gin.New()
+	handler := func(c *gin.Context) { c.String(http.StatusOK, searcherFrom(c).Hex()) }
+	router.POST("/relay/v1/bundle", searcherAuth(), handler)
+	router.GET("/relay/v1/bundle/:hash", searcherAuth(), handler)
+
+	const body = `{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[]}`
+	const path = "/relay/v1/bundle/0xabc?blockNumber=0x10"
+	now := time.Now().Unix()
+	signedGET := func(path string, timestamp int64) string {
+		return "GET " + path + " " + strconv.FormatInt(timestamp, 10)
+	}
+	// Flip a bit in the signature's r value.
+	tampered := []byte(signHeader(t, key, body, 0))
+	i := len(searcher.Hex()) + len(":0x") + 10
+	tampered[i] = "1032547698badcfe"[strings.IndexByte("0123456789abcdef", tampered[i])]
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		signature string
+		timestamp string
+		want      int
+	}{
+		{"signed body", http.MethodPost, "/relay/v1/bundle", body, signHeader(t, key, body, 0), "", http.StatusOK},
+		{"signed body with 27/28 recovery id", http.MethodPost, "/relay/v1/bundle", body, signHeader(t, key, body, 27), "", http.StatusOK},
+		{"missing header", http.MethodPost, "/relay/v1/bundle", body, "", "", http.StatusUnauthorized},
+		{"header without address", http.MethodPost, "/relay/v1/bundle", body, strings.SplitN(signHeader(t, key, body, 0), ":", 2)[1], "", http.StatusUnauthorized},
+		{"short signature", http.MethodPost, "/relay/v1/bundle", body, searcher.Hex() + ":0x1234", "", http.StatusUnauthorized},
+		{"tampered signature", http.MethodPost, "/relay/v1/bundle", body, string(tampered), "", http.StatusUnauthorized},
+		{"other body", http.MethodPost, "/relay/v1/bundle", body + " ", signHeader(t, key, body, 0), "", http.StatusUnauthorized},
+		{"claimed address is not the signer", http.MethodPost, "/relay/v1/bundle", body,
+			crypto.PubkeyToAddress(other.PublicKey).Hex() + ":" + strings.SplitN(signHeader(t, key, body, 0), ":", 2)[1], "", http.StatusUnauthorized},
+		{"oversized body", http.MethodPost, "/relay/v1/bundle", strings.Repeat(" ", maxBodyBytes+1), "", "", http.StatusBadRequest},
+
+		{"signed GET", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now), 0), strconv.FormatInt(now, 10), http.StatusOK},
+		{"GET without timestamp", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now), 0), "", http.StatusUnauthorized},
+		{"GET with malformed timestamp", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now), 0), "yesterday", http.StatusUnauthorized},
+		{"GET signed for another path", http.MethodGet, path, "", signHeader(t, key, signedGET("/relay/v1/bundle/0xdef", now), 0), strconv.FormatInt(now, 10), http.StatusUnauthorized},
+		{"GET with another timestamp", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now), 0), strconv.FormatInt(now+1, 10), http.StatusUnauthorized},
+		{"GET signed over an empty body", http.MethodGet, path, "", signHeader(t, key, "", 0), strconv.FormatInt(now, 10), http.StatusUnauthorized},
+		{"stale GET", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now-60), 0), strconv.FormatInt(now-60, 10), http.StatusUnauthorized},
+		{"GET from the future", http.MethodGet, path, "", signHeader(t, key, signedGET(path, now+60), 0), strconv.FormatInt(now+60, 10), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set(signatureHeader, tt.signature)
+			}
+			if tt.timestamp != "" {
+				req.Header.Set(timestampHeader, tt.timestamp)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusOK && common.HexToAddress(w.Body.String()) != searcher {
+				t.Errorf("searcher = %s, want %s", w.Body.String(), searcher.Hex())
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/beacon"
+	"mev-relay/internal/pb"
+)
+
+// bidRetention is how many slots of bids are kept behind the newest one.
+const bidRetention = 64
+
+// consensusVersion is the fork of the builder API payloads served.
+const consensusVersion = "deneb"
+
+// bid is the most valuable block the builders produced for a slot. On the
+// local devnet a slot is the number of the block being built.
+type bid struct {
+	Slot          uint64
+	Builder       string
+	BuilderPubkey string
+	BundleID      string
+	BlockHash     common.Hash
+	Value         *big.Int
+	// FeeRecipient receives the proposer payment; zero when the builder
+	// built without knowing the slot's proposer.
+	FeeRecipient common.Address
+	GasUsed      uint64
+	Txs          []hexutil.Bytes
+	ReceivedAt   time.Time
+}
+
+// servedBid is a bid whose header was handed to a proposer, kept so that
+// the payload can be revealed once the proposer signs the blinded block.
+type servedBid struct {
+	Bid         *bid
+	Proposer    beacon.PublicKey
+	Payload     *beacon.ExecutionPayload
+	HeaderRoot  [32]byte
+	ServedAt    time.Time
+	DeliveredAt time.Time
+}
+
+// bidStore keeps, for recent slots, the best bid paying each fee recipient
+// and the header served to the slot's proposer.
+type bidStore struct {
+	mu     sync.Mutex
+	best   map[uint64]map[common.Address]*bid
+	served map[uint64]*servedBid
+	newest uint64
+}
+
+func newBidStore() *bidStore {
+	return &bidStore{
+		best:   make(map[uint64]map[common.Address]*bid),
+		served: make(map[uint64]*servedBid),
+	}
+}
+
+// offer keeps b if it pays the proposer more than the slot's current bid to
+// the same fee recipient.
+func (s *bidStore) offer(b *bid) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bids, ok := s.best[b.Slot]
+	if !ok {
+		bids = make(map[common.Address]*bid)
+		s.best[b.Slot] = bids
+	}
+	if current, ok := bids[b.FeeRecipient]; ok && current.Value.Cmp(b.Value) >= 0 {
+		return
+	}
+	bids[b.FeeRecipient] = b
+
+	if b.Slot > s.newest {
+		s.newest = b.Slot
+		s.prune()
+	}
+}
+
+// bestFor returns the slot's most valuable bid paying feeRecipient, or nil
+// if there is none.
+func (s *bidStore) bestFor(slot uint64, feeRecipient common.Address) *bid {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.best[slot][feeRecipient]
+}
+
+func (s *bidStore) serve(slot uint64, served *servedBid) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.served[slot] = served
+}
+
+// deliver returns the payload served for the slot if it was served to the
+// proposer and matches the header the proposer signed, and marks it
+// delivered. first reports whether this is the first time the payload is
+// delivered.
+func (s *bidStore) deliver(slot uint64, proposer beacon.PublicKey, headerRoot [32]byte) (served *servedBid, first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	served, ok := s.served[slot]
+	if !ok || served.Proposer != proposer || served.HeaderRoot != headerRoot {
+		return nil, false
+	}
+	if !served.DeliveredAt.IsZero() {
+		return served, false
+	}
+	served.DeliveredAt = time.Now()
+	return served, true
+}
+
+// prune drops slots more than bidRetention behind the newest. Callers must
+// hold mu.
+func (s *bidStore) prune() {
+	for slot := range s.best {
+		if slot+bidRetention < s.newest {
+			delete(s.best, slot)
+		}
+	}
+	for slot := range s.served {
+		if slot+bidRetention < s.newest {
+			delete(s.served, slot)
+		}
+	}
+}
+
+// bidFromBuild turns a builder's block into a bid for its slot.
+func bidFromBuild(builder string, result *pb.BuildResult) (*bid, bool) {
+	value, ok := new(big.Int).SetString(result.Value, 10)
+	if !ok || result.BlockNumber == 0 {
+		return nil, false
+	}
+
+	txs := make([]hexutil.Bytes, 0, len(result.Txs))
+	for _, raw := range result.Txs {
+		tx, err := hexutil.Decode(raw)
+		if err != nil {
+			return nil, false
+		}
+		txs = append(txs, tx)
+	}
+
+	return &bid{
+		Slot:          result.BlockNumber,
+		Builder:       builder,
+		BuilderPubkey: result.BuilderPubkey,
+		BundleID:      result.BundleId,
+		BlockHash:     common.HexToHash(result.BlockHash),
+		Value:         value,
+		FeeRecipient:  common.HexToAddress(result.ProposerFeeRecipient),
+		GasUsed:       result.GasUsed,
+		Txs:           txs,
+		ReceivedAt:    time.Now(),
+	}, true
+}
+
+// builderAPIError writes an error in the builder API format.
+func builderAPIError(c *gin.Context, code int, message string) {
+	c.JSON(code, gin.H{"code": code, "message": message})
+}
+
+// handleBuilderStatus serves GET /eth/v1/builder/status.
+func (s *Server) handleBuilderStatus(c *gin.Context) {
+	c.Status(http.StatusOK)
+}
+
+// handleRegisterValidators serves POST /eth/v1/builder/validators.
+func (s *Server) handleRegisterValidators(c *gin.Context) {
+	var registrations []*beacon.SignedValidatorRegistration
+	if err := json.NewDecoder(c.Request.Body).Decode(&registrations); err != nil {
+		builderAPIError(c, http.StatusBadRequest, "invalid registrations: "+err.Error())
+		return
+	}
+
+	for i, registration := range registrations {
+		if registration == nil || registration.Message == nil {
+			builderAPIError(c, http.StatusBadRequest, "registration "+strconv.Itoa(i)+" has no message")
+			return
+		}
+	}
+
+	err := s.validators.register(c.Request.Context(), registrations)
+	switch {
+	case errors.Is(err, errStoringRegistrations):
+		log.Printf("[Relay] Failed to store %d validator registrations: %v", len(registrations), err)
+		builderAPIError(c, http.StatusInternalServerError, errStoringRegistrations.Error())
+		return
+	case err != nil:
+		builderAPIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("[Relay] Registered %d validators", len(registrations))
+	c.Status(http.StatusOK)
+}
+
+// handleGetHeader serves GET /eth/v1/builder/header/:slot/:parent_hash/:pubkey
+// with the signed header of the slot's best bid paying the proposer's fee
+// recipient. It answers 204 when there is no such bid for the slot and
+// parent, or the pubkey is not the slot's scheduled proposer.
+func (s *Server) handleGetHeader(c *gin.Context) {
+	slot, err := strconv.ParseUint(c.Param("slot"), 10, 64)
+	if err != nil || slot == 0 {
+		builderAPIError(c, http.StatusBadRequest, "invalid slot")
+		return
+	}
+	parentHash, err := hexutil.Decode(c.Param("parent_hash"))
+	if err != nil || len(parentHash) != common.HashLength {
+		builderAPIError(c, http.StatusBadRequest, "invalid parent hash")
+		return
+	}
+	var proposer beacon.PublicKey
+	if err := proposer.UnmarshalText([]byte(c.Param("pubkey"))); err != nil {
+		builderAPIError(c, http.StatusBadRequest, "invalid pubkey")
+		return
+	}
+
+	// Only the scheduled proposer may be served, so that no other validator
+	// can replace the header served for the slot.
+	registration := s.validators.proposer(slot)
+	if registration == nil || registration.Pubkey != proposer {
+		log.Printf("[Relay] No header for slot %d: %s is not the slot's proposer", slot, proposer)
+		c.Status(http.StatusNoContent)
+		return
+	}
+	best := s.bids.bestFor(slot, registration.FeeRecipient)
+	if best == nil {
+		log.Printf("[Relay] No header for slot %d: no bid pays %s's fee recipient %s",
+			slot, proposer, registration.FeeRecipient.Hex())
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	parent, err := s.eth.HeaderByNumber(c.Request.Context(), new(big.Int).SetUint64(slot-1))
+	if err != nil || parent.Hash() != common.BytesToHash(parentHash) {
+		log.Printf("[Relay] No header for slot %d: parent %s is not the chain's", slot, hexutil.Encode(parentHash))
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	baseFee := new(big.Int)
+	if parent.BaseFee != nil {
+		baseFee = parent.BaseFee
+	}
+	payload := &beacon.ExecutionPayload{
+		ParentHash:    parent.Hash(),
+		FeeRecipient:  registration.FeeRecipient,
+		BlockNumber:   slot,
+		GasLimit:      registration.GasLimit,
+		GasUsed:       best.GasUsed,
+		Timestamp:     parent.Time + uint64(s.cfg.AnvilBlockTime),
+		ExtraData:     hexutil.Bytes{},
+		BaseFeePerGas: beacon.NewU256(baseFee),
+		BlockHash:     best.BlockHash,
+		Transactions:  best.Txs,
+		Withdrawals:   []*beacon.Withdrawal{},
+	}
+
+	message := &beacon.BuilderBid{
+		Header:             payload.Header(),
+		BlobKZGCommitments: []hexutil.Bytes{},
+		Value:              beacon.NewU256(best.Value),
+		Pubkey:             s.blsKey.PublicKey(),
+	}
+	signingRoot := beacon.SigningRoot(message.HashTreeRoot(), s.builderDomain)
+	signed := &beacon.SignedBuilderBid{Message: message, Signature: s.blsKey.Sign(signingRoot[:])}
+
+	s.bids.serve(slot, &servedBid{
+		Bid:        best,
+		Proposer:   proposer,
+		Payload:    payload,
+		HeaderRoot: message.Header.HashTreeRoot(),
+		ServedAt:   time.Now(),
+	})
+	log.Printf("[Relay] Served header %s for slot %d to %s (value %s wei)", best.BlockHash.Hex(), slot, proposer, best.Value)
+
+	c.JSON(http.StatusOK, beacon.VersionedResponse{Version: consensusVersion, Data: signed})
+}
+
+// handleGetPayload serves POST /eth/v1/builder/blinded_blocks: the payload
+// is revealed once the slot's proposer has signed a block committing to the
+// header served for the slot. Blocks of the local chain carry only the
+// payload header, so the proposer signs the header root rather than a beacon
+// block root.
+func (s *Server) handleGetPayload(c *gin.Context) {
+	var block beacon.SignedBlindedBeaconBlock
+	if err := json.NewDecoder(c.Request.Body).Decode(&block); err != nil {
+		builderAPIError(c, http.StatusBadRequest, "invalid blinded block: "+err.Error())
+		return
+	}
+	if block.Message == nil || block.Message.Body == nil || block.Message.Body.ExecutionPayloadHeader == nil {
+		builderAPIError(c, http.StatusBadRequest, "blinded block has no execution payload header")
+		return
+	}
+
+	slot := block.Message.Slot
+	header := block.Message.Body.ExecutionPayloadHeader
+	headerRoot := header.HashTreeRoot()
+
+	proposer := s.validators.proposer(slot)
+	if proposer == nil {
+		builderAPIError(c, http.StatusBadRequest, "no registered proposer for this slot")
+		return
+	}
+	signingRoot := beacon.SigningRoot(headerRoot, s.proposerDomain)
+	if !beacon.Verify(proposer.Pubkey, signingRoot[:], block.Signature) {
+		log.Printf("[Relay] Rejected blinded block for slot %d: signature does not verify against proposer %s", slot, proposer.Pubkey)
+		builderAPIError(c, http.StatusBadRequest, "invalid proposer signature")
+		return
+	}
+
+	served, first := s.bids.deliver(slot, proposer.Pubkey, headerRoot)
+	if served == nil {
+		builderAPIError(c, http.StatusBadRequest, "no header was served for this block")
+		return
+	}
+	if first {
+		s.recorder.record(c.Request.Context(), deliveredRow(served))
+		s.countDelivery(served)
+	}
+
+	log.Printf("[Relay] Delivered payload %s for slot %d to %s", header.BlockHash.Hex(), slot, served.Proposer)
+	c.JSON(http.StatusOK, beacon.VersionedResponse{
+		Version: consensusVersion,
+		Data: &beacon.ExecutionPayloadAndBlobsBundle{
+			ExecutionPayload: served.Payload,
+			BlobsBundle: &beacon.BlobsBundle{
+				Commitments: []hexutil.Bytes{},
+				Proofs:      []hexutil.Bytes{},
+				Blobs:       []hexutil.Bytes{},
+			},
+		},
+	})
+}
@@ -0,0 +1,310 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/internal/beacon"
+	"mev-relay/internal/config"
+)
+
+func TestHandleGetPayloadVerifiesProposerSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const slot = 7
+	forkVersion := [4]byte{}
+	proposerDomain := beacon.ComputeDomain(beacon.DomainTypeBeaconProposer, forkVersion, common.Hash{})
+
+	proposerKey, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &dbRecorder{rows: make(chan recordedRow, 16)}
+	validators := newValidatorRegistry(nil, beacon.BuilderDomain(forkVersion))
+	validators.store(&beacon.SignedValidatorRegistration{
+		Message: &beacon.ValidatorRegistration{
+			FeeRecipient: common.HexToAddress("0x1"),
+			GasLimit:     30_000_000,
+			Timestamp:    1,
+			Pubkey:       proposerKey.PublicKey(),
+		},
+	})
+
+	payload := &beacon.ExecutionPayload{
+		FeeRecipient:  common.HexToAddress("0x1"),
+		BlockNumber:   slot,
+		GasLimit:      30_000_000,
+		ExtraData:     hexutil.Bytes{},
+		BaseFeePerGas: beacon.NewU256(big.NewInt(7)),
+		BlockHash:     common.HexToHash("0xb10c"),
+		Transactions:  []hexutil.Bytes{},
+		Withdrawals:   []*beacon.Withdrawal{},
+	}
+	header := payload.Header()
+	headerRoot := header.HashTreeRoot()
+
+	s := &Server{
+		recorder:       recorder,
+		proposerDomain: proposerDomain,
+		validators:     validators,
+		bids:           newBidStore(),
+	}
+	s.bids.serve(slot, &servedBid{
+		Bid:        &bid{Slot: slot, BlockHash: payload.BlockHash, Value: big.NewInt(1)},
+		Proposer:   proposerKey.PublicKey(),
+		Payload:    payload,
+		HeaderRoot: headerRoot,
+	})
+
+	router := gin.New()
+	router.POST("/eth/v1/builder/blinded_blocks", s.handleGetPayload)
+
+	signingRoot := beacon.SigningRoot(headerRoot, proposerDomain)
+	valid := proposerKey.Sign(signingRoot[:])
+	tampered := valid
+	tampered[len(tampered)-1] ^= 0x01
+	wrongDomain := beacon.SigningRoot(headerRoot, beacon.BuilderDomain(forkVersion))
+
+	// The valid signature goes last: a rejected block must not mark the
+	// payload delivered.
+	tests := []struct {
+		name      string
+		signature beacon.Signature
+		want      int
+	}{
+		{"tampered signature", tampered, http.StatusBadRequest},
+		{"signed by another validator", otherKey.Sign(signingRoot[:]), http.StatusBadRequest},
+		{"signed under the builder domain", proposerKey.Sign(wrongDomain[:]), http.StatusBadRequest},
+		{"empty signature", beacon.Signature{}, http.StatusBadRequest},
+		{"signed by the slot's proposer", valid, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(&beacon.SignedBlindedBeaconBlock{
+				Message: &beacon.BlindedBeaconBlock{
+					Slot: slot,
+					Body: &beacon.BlindedBeaconBlockBody{ExecutionPayloadHeader: header},
+				},
+				Signature: tt.signature,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/eth/v1/builder/blinded_blocks", bytes.NewReader(body))
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want != http.StatusOK && len(recorder.rows) != 0 {
+				t.Fatal("rejected block recorded a delivered payload")
+			}
+		})
+	}
+
+	if len(recorder.rows) != 1 {
+		t.Fatalf("recorded %d delivered payloads, want 1", len(recorder.rows))
+	}
+}
+
+// fakeChainNode serves parent as the chain's block of its number.
+func fakeChainNode(t *testing.T, parent *types.Header) *ethclient.Client {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": parent})
+	}))
+	t.Cleanup(node.Close)
+
+	client, err := rpc.Dial(node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ethclient.NewClient(client)
+}
+
+func TestHandleGetHeaderServesScheduledProposer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const slot = 9
+	parent := &types.Header{Number: big.NewInt(slot - 1), Time: 1_000, BaseFee: big.NewInt(7), Difficulty: new(big.Int)}
+
+	validators := newValidatorRegistry(nil, beacon.Domain{})
+	keys := make(map[beacon.PublicKey]common.Address)
+	for i := 1; i <= 2; i++ {
+		key, err := beacon.GenerateSecretKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeRecipient := common.BigToAddress(big.NewInt(int64(i)))
+		keys[key.PublicKey()] = feeRecipient
+		validators.store(&beacon.SignedValidatorRegistration{
+			Message: &beacon.ValidatorRegistration{FeeRecipient: feeRecipient, GasLimit: 30_000_000, Timestamp: 1, Pubkey: key.PublicKey()},
+		})
+	}
+	scheduled := validators.proposer(slot)
+	var other beacon.PublicKey
+	for pubkey := range keys {
+		if pubkey != scheduled.Pubkey {
+			other = pubkey
+		}
+	}
+	unregistered, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relayKey, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		cfg:        &config.Config{AnvilBlockTime: 2},
+		eth:        fakeChainNode(t, parent),
+		validators: validators,
+		bids:       newBidStore(),
+		blsKey:     relayKey,
+	}
+	// The most valuable bid pays the other validator's fee recipient.
+	s.bids.offer(&bid{Slot: slot, BlockHash: common.HexToHash("0xa"), Value: big.NewInt(5), FeeRecipient: keys[other]})
+	s.bids.offer(&bid{Slot: slot, BlockHash: common.HexToHash("0xb"), Value: big.NewInt(2), FeeRecipient: scheduled.FeeRecipient})
+	s.bids.offer(&bid{Slot: slot, BlockHash: common.HexToHash("0xc"), Value: big.NewInt(1), FeeRecipient: scheduled.FeeRecipient})
+
+	router := gin.New()
+	router.GET("/eth/v1/builder/header/:slot/:parent_hash/:pubkey", s.handleGetHeader)
+
+	// The scheduled proposer goes first: later requests must not replace
+	// the header it was served.
+	tests := []struct {
+		name   string
+		pubkey beacon.PublicKey
+		want   int
+	}{
+		{"scheduled proposer", scheduled.Pubkey, http.StatusOK},
+		{"registered validator not scheduled for the slot", other, http.StatusNoContent},
+		{"unregistered validator", unregistered.PublicKey(), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			path := "/eth/v1/builder/header/9/" + parent.Hash().Hex() + "/" + tt.pubkey.String()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	served := s.bids.served[slot]
+	if served == nil || served.Proposer != scheduled.Pubkey {
+		t.Fatal("header served for the slot is not the scheduled proposer's")
+	}
+	if served.Bid.BlockHash != common.HexToHash("0xb") {
+		t.Errorf("served bid %s, want the best one paying the proposer's fee recipient", served.Bid.BlockHash.Hex())
+	}
+	if served.Payload.FeeRecipient != scheduled.FeeRecipient {
+		t.Errorf("payload fee recipient = %s, want %s", served.Payload.FeeRecipient.Hex(), scheduled.FeeRecipient.Hex())
+	}
+}
+
+func TestBidStoreDeliversOnlyToServedProposer(t *testing.T) {
+	proposer, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := [32]byte{1}
+
+	store := newBidStore()
+	store.serve(7, &servedBid{Bid: &bid{Slot: 7}, Proposer: proposer.PublicKey(), HeaderRoot: root})
+
+	if served, _ := store.deliver(7, other.PublicKey(), root); served != nil {
+		t.Fatal("delivered a payload to a proposer it was not served to")
+	}
+	if served, first := store.deliver(7, proposer.PublicKey(), root); served == nil || !first {
+		t.Fatal("payload not delivered to the proposer it was served to")
+	}
+}
+
+func TestHandleRegisterValidatorsStoresBeforeAcknowledging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Nothing listens on the port, so every write fails.
+	pool, err := pgxpool.New(context.Background(), "postgres://postgres@127.0.0.1:1/mevrelay?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	domain := beacon.BuilderDomain([4]byte{})
+	key, err := beacon.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &beacon.ValidatorRegistration{
+		FeeRecipient: common.HexToAddress("0x1"),
+		GasLimit:     30_000_000,
+		Timestamp:    uint64(time.Now().Unix()),
+		Pubkey:       key.PublicKey(),
+	}
+	root := beacon.SigningRoot(message.HashTreeRoot(), domain)
+	valid := key.Sign(root[:])
+
+	tests := []struct {
+		name      string
+		signature beacon.Signature
+		want      int
+	}{
+		{"invalid signature", beacon.Signature{}, http.StatusBadRequest},
+		{"valid registration the database cannot store", valid, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{validators: newValidatorRegistry(pool, domain)}
+			router := gin.New()
+			router.POST("/eth/v1/builder/validators", s.handleRegisterValidators)
+
+			body, err := json.Marshal([]*beacon.SignedValidatorRegistration{{Message: message, Signature: tt.signature}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/eth/v1/builder/validators", bytes.NewReader(body)))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if s.validators.get(key.PublicKey()) != nil {
+				t.Fatal("registration kept although it was not stored")
+			}
+		})
+	}
+}
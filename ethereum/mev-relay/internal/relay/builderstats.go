@@ -0,0 +1,180 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"mev-relay/internal/pb"
+)
+
+// forward submits a bundle within the builder's timeout and counts the
+// answer in the builder's stats.
+func (c *builderClient) forward(ctx context.Context, submission *pb.BundleSubmission) builderOutcome {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := c.submit(ctx, submission)
+	outcome := builderOutcome{Builder: c.addr, Result: result, Err: err, Latency: time.Since(start), At: time.Now()}
+	c.stats.record(outcome)
+	return outcome
+}
+
+// timedOut reports whether a submission failed by running out of time.
+func timedOut(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// accepted reports whether the builder built a block with the bundle,
+// whether or not the bundle was selected for it.
+func accepted(outcome builderOutcome) bool {
+	return outcome.Err == nil && outcome.Result.BlockHash != ""
+}
+
+// builderStats counts a builder's answers to forwarded bundles, and the
+// payloads of its blocks delivered to proposers.
+type builderStats struct {
+	mu        sync.Mutex
+	pubkey    string // as reported in the builder's blocks
+	submitted uint64
+	accepted  uint64
+	failed    uint64
+	timedOut  uint64
+	latency   time.Duration // total over answered submissions
+	slotsBid  uint64        // slots the builder returned a block for
+	delivered uint64
+	// slots holds the recent slots counted in slotsBid, the newest
+	// bidRetention of them, as bundles reach the builder in no particular
+	// target order.
+	slots      map[uint64]bool
+	newestSlot uint64
+}
+
+func (st *builderStats) record(outcome builderOutcome) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.submitted++
+	switch {
+	case outcome.Err != nil && timedOut(outcome.Err):
+		st.timedOut++
+		return
+	case outcome.Err != nil:
+		st.failed++
+		return
+	}
+
+	st.latency += outcome.Latency
+	if !accepted(outcome) {
+		return
+	}
+	st.accepted++
+	st.pubkey = outcome.Result.BuilderPubkey
+	st.countSlot(outcome.Result.BlockNumber)
+}
+
+// countSlot counts a slot the builder returned a block for, unless it was
+// counted already. Slots more than bidRetention behind the newest may have
+// been pruned and are not counted. Callers must hold mu.
+func (st *builderStats) countSlot(slot uint64) {
+	if st.slots == nil {
+		st.slots = make(map[uint64]bool)
+	}
+	if st.slots[slot] || slot+bidRetention < st.newestSlot {
+		return
+	}
+	st.slots[slot] = true
+	st.slotsBid++
+
+	if slot > st.newestSlot {
+		st.newestSlot = slot
+		for counted := range st.slots {
+			if counted+bidRetention < st.newestSlot {
+				delete(st.slots, counted)
+			}
+		}
+	}
+}
+
+// countDelivery counts a payload delivered to a proposer from one of the
+// builder's blocks.
+func (st *builderStats) countDelivery() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.delivered++
+}
+
+// BuilderStats compares how builders handled the bundles forwarded to them.
+// Included counts the builder's blocks whose payload was delivered to the
+// proposer, the rows of payloads_delivered with the builder's pubkey, and
+// InclusionRate is over the slots the builder bid for.
+type BuilderStats struct {
+	Builder        string  `json:"builder"`
+	BuilderPubkey  string  `json:"builderPubkey,omitempty"`
+	TimeoutMs      int64   `json:"timeoutMs"`
+	Submitted      uint64  `json:"submitted"`
+	Accepted       uint64  `json:"accepted"`
+	SlotsBid       uint64  `json:"slotsBid"`
+	Included       uint64  `json:"included"`
+	Failed         uint64  `json:"failed"`
+	TimedOut       uint64  `json:"timedOut"`
+	AcceptanceRate float64 `json:"acceptanceRate"`
+	InclusionRate  float64 `json:"inclusionRate"`
+	AvgLatencyMs   float64 `json:"avgLatencyMs"`
+}
+
+func (c *builderClient) snapshot() BuilderStats {
+	st := &c.stats
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	stats := BuilderStats{
+		Builder:       c.addr,
+		BuilderPubkey: st.pubkey,
+		TimeoutMs:     c.timeout.Milliseconds(),
+		Submitted:     st.submitted,
+		Accepted:      st.accepted,
+		SlotsBid:      st.slotsBid,
+		Included:      st.delivered,
+		Failed:        st.failed,
+		TimedOut:      st.timedOut,
+	}
+	if st.submitted > 0 {
+		stats.AcceptanceRate = float64(st.accepted) / float64(st.submitted)
+	}
+	if st.slotsBid > 0 {
+		stats.InclusionRate = float64(st.delivered) / float64(st.slotsBid)
+	}
+	if answered := st.submitted - st.failed - st.timedOut; answered > 0 {
+		stats.AvgLatencyMs = float64(st.latency.Milliseconds()) / float64(answered)
+	}
+	return stats
+}
+
+// countDelivery credits the delivered payload to the builder whose block it
+// is.
+func (s *Server) countDelivery(served *servedBid) {
+	for _, builder := range s.builders {
+		if builder.addr == served.Bid.Builder {
+			builder.stats.countDelivery()
+			return
+		}
+	}
+}
+
+// handleBuilderStats serves GET /relay/v1/builders with every builder's
+// stats since the relay started. The builder_submissions table keeps the
+// full history.
+func (s *Server) handleBuilderStats(c *gin.Context) {
+	stats := make([]BuilderStats, 0, len(s.builders))
+	for _, builder := range s.builders {
+		stats = append(stats, builder.snapshot())
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,44 @@
+package relay
+
+import (
+	"testing"
+
+	"mev-relay/internal/pb"
+)
+
+func TestBuilderStatsCountsDistinctSlots(t *testing.T) {
+	tests := []struct {
+		name  string
+		slots []uint64
+		want  uint64
+	}{
+		{"in order", []uint64{10, 11, 12}, 3},
+		{"out of order", []uint64{12, 10, 11}, 3},
+		{"repeated slots", []uint64{11, 10, 11, 12, 10}, 3},
+		{"earlier slot after a later one", []uint64{20, 15, 20, 15}, 2},
+		{"slot behind the retention window", []uint64{100, 100 - bidRetention, 100 - bidRetention - 1}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var st builderStats
+			for _, slot := range tt.slots {
+				st.record(builderOutcome{Result: &pb.BuildResult{BlockHash: "0x01", BlockNumber: slot}})
+			}
+			if st.slotsBid != tt.want {
+				t.Errorf("slots bid = %d, want %d", st.slotsBid, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilderStatsInclusionRate(t *testing.T) {
+	client := &builderClient{addr: "builder:50052"}
+	for _, slot := range []uint64{12, 10, 11, 13} {
+		client.stats.record(builderOutcome{Result: &pb.BuildResult{BlockHash: "0x01", BlockNumber: slot}})
+	}
+	client.stats.countDelivery()
+
+	if got := client.snapshot().InclusionRate; got != 0.25 {
+		t.Errorf("inclusion rate = %v, want 0.25", got)
+	}
+}
@@ -0,0 +1,232 @@
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"mev-relay/internal/pb"
+)
+
+// bundleRetention is how long a bundle is remembered for deduplication
+// and status lookups.
+const bundleRetention = 30 * time.Minute
+
+// bundleHash returns the Flashbots-compatible bundle hash, the keccak256 of
+// the concatenated hashes of the bundle's transactions.
+func bundleHash(txs []decodedTx) string {
+	hashes := make([]byte, 0, len(txs)*32)
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes).Hex()
+}
+
+// bundleRecord tracks one bundle submission for a target block.
+type bundleRecord struct {
+	Hash            string
+	Searcher        common.Address
+	TargetBlock     string
+	ReplacementUUID string
+	// MatchedTxHash is the shared user transaction the bundle carries. Its
+	// simulation results are not shown to the searcher.
+	MatchedTxHash string
+	ReceivedAt    time.Time
+
+	// forwardMu is held from the last cancellation check until the
+	// builders have answered the forwarded bundle, so that a cancellation
+	// either stops the forward or waits for it and its builder cancel
+	// arrives after the submission.
+	forwardMu    sync.Mutex
+	mu           sync.Mutex
+	simulatedAt  time.Time
+	result       *pb.BundleResponse
+	err          error
+	builds       []builderOutcome
+	cancelReason string
+}
+
+// builderOutcome is the answer of one builder to a forwarded bundle.
+type builderOutcome struct {
+	Builder string
+	Result  *pb.BuildResult
+	Err     error
+	Latency time.Duration
+	At      time.Time
+}
+
+// finish stores the simulation outcome of the bundle.
+func (r *bundleRecord) finish(result *pb.BundleResponse, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.simulatedAt = time.Now()
+	r.result = result
+	r.err = err
+}
+
+// cancel marks the bundle as cancelled so that it is neither simulated nor
+// forwarded to the builders any more. A forward already under way is waited
+// for.
+func (r *bundleRecord) cancel(reason string) {
+	r.forwardMu.Lock()
+	defer r.forwardMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancelReason == "" {
+		r.cancelReason = reason
+	}
+}
+
+func (r *bundleRecord) cancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelReason != ""
+}
+
+// reusable reports whether a resubmission of the same bundle should be
+// answered with this record. Bundles whose simulation could not run or that
+// were cancelled are submitted afresh.
+func (r *bundleRecord) reusable() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err == nil && r.cancelReason == ""
+}
+
+// addBuild stores a builder's answer to the bundle.
+func (r *bundleRecord) addBuild(outcome builderOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.builds = append(r.builds, outcome)
+}
+
+// bundleStore remembers recent submissions so that a bundle resubmitted
+// for the same target block is not simulated twice.
+type bundleStore struct {
+	mu      sync.Mutex
+	records map[string]*bundleRecord
+	// byUUID holds the current bundle of each searcher's replacement UUID.
+	byUUID map[string]*bundleRecord
+}
+
+func newBundleStore() *bundleStore {
+	return &bundleStore{
+		records: make(map[string]*bundleRecord),
+		byUUID:  make(map[string]*bundleRecord),
+	}
+}
+
+// add registers a bundle, returning the existing record and false when the
+// same bundle was already submitted for the same target block. Records that
+// are no longer reusable are replaced.
+func (s *bundleStore) add(hash string, searcher common.Address, opts bundleOptions) (*bundleRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	key := recordKey(searcher, hash, opts.TargetBlock)
+	if existing, ok := s.records[key]; ok && existing.reusable() {
+		return existing, false
+	}
+
+	record := &bundleRecord{
+		Hash:            hash,
+		Searcher:        searcher,
+		TargetBlock:     opts.TargetBlock,
+		ReplacementUUID: opts.ReplacementUUID,
+		MatchedTxHash:   opts.MatchedTxHash,
+		ReceivedAt:      time.Now(),
+	}
+	s.records[key] = record
+	return record, true
+}
+
+// forget removes the record so the same bundle can be submitted again.
+func (s *bundleStore) forget(record *bundleRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := recordKey(record.Searcher, record.Hash, record.TargetBlock)
+	if s.records[key] == record {
+		delete(s.records, key)
+	}
+}
+
+// replace makes record the current bundle of its replacement UUID and
+// returns the bundle it supersedes, if any.
+func (s *bundleStore) replace(record *bundleRecord) *bundleRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := uuidKey(record.Searcher, record.ReplacementUUID)
+	previous := s.byUUID[key]
+	s.byUUID[key] = record
+	if previous == record {
+		return nil
+	}
+	return previous
+}
+
+// takeUUID removes and returns the current bundle of the searcher's
+// replacement UUID.
+func (s *bundleStore) takeUUID(searcher common.Address, uuid string) *bundleRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := uuidKey(searcher, uuid)
+	record := s.byUUID[key]
+	delete(s.byUUID, key)
+	return record
+}
+
+// prune drops records older than bundleRetention. Callers must hold mu.
+func (s *bundleStore) prune() {
+	cutoff := time.Now().Add(-bundleRetention)
+	for key, record := range s.records {
+		if record.ReceivedAt.Before(cutoff) {
+			delete(s.records, key)
+		}
+	}
+	for key, record := range s.byUUID {
+		if record.ReceivedAt.Before(cutoff) {
+			delete(s.byUUID, key)
+		}
+	}
+}
+
+// find returns the searcher's record of the bundle for the target block, or
+// the searcher's most recent submission of the bundle when targetBlock is
+// empty.
+func (s *bundleStore) find(searcher common.Address, hash, targetBlock string) *bundleRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if targetBlock != "" {
+		return s.records[recordKey(searcher, hash, targetBlock)]
+	}
+
+	var latest *bundleRecord
+	for _, record := range s.records {
+		if record.Hash != hash || record.Searcher != searcher {
+			continue
+		}
+		if latest == nil || record.ReceivedAt.After(latest.ReceivedAt) {
+			latest = record
+		}
+	}
+	return latest
+}
+
+// recordKey identifies a searcher's submission of a bundle. Two searchers
+// sending the same transactions get separate records.
+func recordKey(searcher common.Address, hash, targetBlock string) string {
+	return searcher.Hex() + "/" + hash + "@" + targetBlock
+}
+
+func uuidKey(searcher common.Address, uuid string) string {
+	return searcher.Hex() + "/" + uuid
+}
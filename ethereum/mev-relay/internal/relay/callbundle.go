@@ -0,0 +1,115 @@
+package relay
+
+import (
+	"encoding/json"
+	"log"
+	"math/big"
+
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/pb"
+)
+
+// CallBundleParams are the parameters of eth_callBundle.
+type CallBundleParams struct {
+	Txs              []string `json:"txs"`
+	BlockNumber      string   `json:"blockNumber"`
+	StateBlockNumber string   `json:"stateBlockNumber"`
+	Timestamp        int64    `json:"timestamp,omitempty"`
+}
+
+// handleCallBundle serves eth_callBundle: the bundle is simulated right away
+// and the per-transaction results are returned in the Flashbots format. The
+// bundle is neither queued nor forwarded to the builders.
+func (s *Server) handleCallBundle(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var paramsList []CallBundleParams
+	if err := json.Unmarshal(req.Params, &paramsList); err != nil || len(paramsList) == 0 {
+		return nil, invalidParams("missing params")
+	}
+	params := paramsList[0]
+
+	if len(params.Txs) == 0 {
+		return nil, invalidParams("bundle has no transactions")
+	}
+	if _, rpcErr := s.validateTarget(c.Request.Context(), params.BlockNumber); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if params.Timestamp < 0 {
+		return nil, invalidParams("timestamp must not be negative")
+	}
+
+	txs, rpcErr := decodeTxs(params.Txs, big.NewInt(s.cfg.ChainID))
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if rpcErr := s.screenBundle(c.Request.Context(), searcherFrom(c), txs, params.BlockNumber); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	hash := bundleHash(txs)
+	log.Printf("[Relay] Simulating bundle %s for %s (eth_callBundle)", hash, searcherFrom(c).Hex())
+
+	result, err := s.simulator.simulate(c.Request.Context(), &pb.BundleRequest{
+		BundleId:    hash,
+		Txs:         params.Txs,
+		TargetBlock: params.BlockNumber,
+		Searcher:    searcherFrom(c).Hex(),
+		StateBlock:  params.StateBlockNumber,
+		Timestamp:   params.Timestamp,
+	})
+	if err != nil {
+		return nil, newRPCError(errCodeSimulation, "simulation unavailable: %v", err)
+	}
+	if len(result.Results) == 0 && !result.Success {
+		return nil, newRPCError(errCodeSimulation, "%s", result.Reason)
+	}
+
+	return callBundleResult(hash, txs, result), nil
+}
+
+// callBundleResult renders a simulation in the Flashbots eth_callBundle
+// response format. Amounts are decimal wei strings; gas prices are the
+// coinbase payment per unit of gas.
+func callBundleResult(hash string, txs []decodedTx, sim *pb.BundleResponse) gin.H {
+	results := make([]gin.H, 0, len(sim.Results))
+	for i, r := range sim.Results {
+		tx := txs[i]
+		res := gin.H{
+			"txHash":            r.TxHash,
+			"fromAddress":       tx.From.Hex(),
+			"gasUsed":           r.GasUsed,
+			"gasPrice":          gasPrice(r.CoinbaseDiff, r.GasUsed),
+			"gasFees":           r.GasFees,
+			"coinbaseDiff":      r.CoinbaseDiff,
+			"ethSentToCoinbase": r.EthSentToCoinbase,
+		}
+		if tx.Tx.To() != nil {
+			res["toAddress"] = tx.Tx.To().Hex()
+		}
+		if r.Error != "" {
+			res["error"] = r.Error
+		} else if !r.Success {
+			res["error"] = "execution reverted"
+			res["revert"] = r.RevertReason
+		}
+		results = append(results, res)
+	}
+
+	return gin.H{
+		"bundleHash":        hash,
+		"bundleGasPrice":    gasPrice(sim.CoinbaseDiff, sim.GasUsed),
+		"coinbaseDiff":      sim.CoinbaseDiff,
+		"ethSentToCoinbase": sim.EthSentToCoinbase,
+		"gasFees":           sim.GasFees,
+		"results":           results,
+		"stateBlockNumber":  sim.StateBlock,
+		"totalGasUsed":      sim.GasUsed,
+	}
+}
+
+func gasPrice(coinbaseDiff string, gasUsed uint64) string {
+	diff, ok := new(big.Int).SetString(coinbaseDiff, 10)
+	if !ok || gasUsed == 0 {
+		return "0"
+	}
+	return diff.Div(diff, new(big.Int).SetUint64(gasUsed)).String()
+}
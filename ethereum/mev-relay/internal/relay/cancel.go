@@ -0,0 +1,61 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"mev-relay/internal/pb"
+)
+
+// CancelBundleParams are the parameters of eth_cancelBundle.
+type CancelBundleParams struct {
+	ReplacementUUID string `json:"replacementUuid"`
+}
+
+// handleCancelBundle serves eth_cancelBundle. The searcher's current bundle
+// for the UUID is dropped from the queue and removed from the builders'
+// pending bundles before their next build.
+func (s *Server) handleCancelBundle(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var params []CancelBundleParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 || params[0].ReplacementUUID == "" {
+		return nil, invalidParams("expected [{replacementUuid}]")
+	}
+
+	searcher := searcherFrom(c)
+	uuid := params[0].ReplacementUUID
+
+	if record := s.bundles.takeUUID(searcher, uuid); record != nil {
+		s.dropBundle(record, "cancelled by searcher")
+	}
+
+	s.cancelAtBuilders(c.Request.Context(), &pb.CancelRequest{Searcher: searcher.Hex(), ReplacementUuid: uuid})
+	return true, nil
+}
+
+// cancelAtBuilders removes the matching pending bundles from every builder.
+func (s *Server) cancelAtBuilders(ctx context.Context, cancel *pb.CancelRequest) {
+	for _, builder := range s.builders {
+		if _, err := builder.cancel(ctx, cancel); err != nil {
+			log.Printf("[Relay] Builder %s failed to cancel %v: %v", builder.addr, cancel, err)
+		}
+	}
+}
+
+// dropBundle cancels a bundle and removes it from the simulation queue.
+// A replaced bundle needs no builder call: builders drop the previous
+// bundle of a replacement UUID when its successor arrives.
+func (s *Server) dropBundle(record *bundleRecord, reason string) {
+	record.cancel(reason)
+	job := s.queue.remove(record)
+	if job != nil {
+		span := trace.SpanFromContext(job.ctx)
+		span.AddEvent("dropped", trace.WithAttributes(attribute.String("reason", reason)))
+		span.End()
+	}
+	s.emit(record, BundleEvent{Type: eventDropped, Reason: reason})
+	log.Printf("[Relay] Bundle %s %s (was queued: %v)", record.Hash, reason, job != nil)
+}
@@ -0,0 +1,134 @@
+package relay
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc"
+	"mev-relay/internal/beacon"
+	"mev-relay/internal/pb"
+)
+
+// stubBuilder logs the calls it receives. SubmitBundle signals submitted,
+// then blocks until release is closed.
+type stubBuilder struct {
+	mu        sync.Mutex
+	calls     []string
+	submitted chan struct{}
+	release   chan struct{}
+}
+
+func (b *stubBuilder) log(call string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, call)
+}
+
+func (b *stubBuilder) SubmitBundle(ctx context.Context, in *pb.BundleSubmission, opts ...grpc.CallOption) (*pb.BuildResult, error) {
+	b.log("submit")
+	close(b.submitted)
+	<-b.release
+	return &pb.BuildResult{BlockHash: "0xb10c", Included: true, BundleId: in.BundleId, Value: "0"}, nil
+}
+
+func (b *stubBuilder) CancelBundle(ctx context.Context, in *pb.CancelRequest, opts ...grpc.CallOption) (*pb.CancelResult, error) {
+	b.log("cancel")
+	return &pb.CancelResult{Removed: 1}, nil
+}
+
+func TestCancelDuringForwardReachesBuilderAfterSubmission(t *testing.T) {
+	stub := &stubBuilder{submitted: make(chan struct{}), release: make(chan struct{})}
+	recorder := &dbRecorder{rows: make(chan recordedRow, 16)}
+	s := &Server{
+		builders:   []*builderClient{{addr: "stub", timeout: time.Minute, client: stub}},
+		queue:      newBundleQueue(1),
+		events:     newEventBus(),
+		recorder:   recorder,
+		validators: newValidatorRegistry(nil, beacon.Domain{}),
+		bids:       newBidStore(),
+	}
+
+	searcher := common.HexToAddress("0x5ea5c4e4000000000000000000000000000000a1")
+	record := &bundleRecord{Hash: "0x01", Searcher: searcher, TargetBlock: "0x10", ReplacementUUID: "uuid"}
+	job := &bundleJob{ctx: context.Background(), record: record, request: &pb.BundleRequest{}, target: 16}
+	sim := &pb.BundleResponse{BundleId: record.Hash, Success: true, CoinbaseDiff: big.NewInt(1).String()}
+
+	forwarded := make(chan struct{})
+	go func() {
+		s.forwardUnlessCancelled(job, sim)
+		close(forwarded)
+	}()
+	<-stub.submitted
+
+	// eth_cancelBundle arrives while the builder is still answering.
+	cancelled := make(chan struct{})
+	go func() {
+		s.dropBundle(record, "cancelled by searcher")
+		s.cancelAtBuilders(context.Background(), &pb.CancelRequest{Searcher: searcher.Hex(), ReplacementUuid: "uuid"})
+		close(cancelled)
+	}()
+
+	select {
+	case <-cancelled:
+		t.Fatal("cancellation finished while the bundle was being forwarded")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(stub.release)
+	<-forwarded
+	<-cancelled
+
+	if len(stub.calls) != 2 || stub.calls[0] != "submit" || stub.calls[1] != "cancel" {
+		t.Fatalf("builder calls = %v, want [submit cancel]", stub.calls)
+	}
+}
+
+func TestForwardSkipsCancelledBundle(t *testing.T) {
+	stub := &stubBuilder{submitted: make(chan struct{}), release: make(chan struct{})}
+	s := &Server{builders: []*builderClient{{addr: "stub", timeout: time.Minute, client: stub}}}
+
+	record := &bundleRecord{Hash: "0x01"}
+	record.cancel("cancelled by searcher")
+	s.forwardUnlessCancelled(&bundleJob{ctx: context.Background(), record: record}, &pb.BundleResponse{Success: true})
+
+	if len(stub.calls) != 0 {
+		t.Fatalf("builder calls = %v, want none", stub.calls)
+	}
+}
+
+func TestEnqueueBundleResubmission(t *testing.T) {
+	searcher := common.HexToAddress("0x5ea5c4e4000000000000000000000000000000a1")
+	tests := []struct {
+		name    string
+		first   string
+		second  string
+		wantErr bool
+	}{
+		{"same UUID", "uuid", "uuid", false},
+		{"no UUID", "", "", false},
+		{"other UUID", "uuid", "other", true},
+		{"UUID added", "", "uuid", true},
+		{"UUID dropped", "uuid", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{bundles: newBundleStore()}
+			first, _ := s.bundles.add(bundleHash(nil), searcher, bundleOptions{TargetBlock: "0x10", ReplacementUUID: tt.first})
+
+			record, rpcErr := s.enqueueBundle(context.Background(), searcher, nil, 16,
+				bundleOptions{TargetBlock: "0x10", ReplacementUUID: tt.second})
+			if tt.wantErr {
+				if rpcErr == nil || rpcErr.Code != errCodeInvalidParams {
+					t.Fatalf("resubmission = %v, want invalid params", rpcErr)
+				}
+				return
+			}
+			if rpcErr != nil || record != first {
+				t.Fatalf("resubmission = %v, %v, want the first record", record, rpcErr)
+			}
+		})
+	}
+}
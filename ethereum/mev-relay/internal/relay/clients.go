@@ -0,0 +1,173 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"mev-relay/internal/pb"
+)
+
+const (
+	callTimeout      = 5 * time.Second
+	maxAttempts      = 3
+	baseBackoff      = 100 * time.Millisecond
+	breakerThreshold = 5
+	breakerCooldown  = 10 * time.Second
+)
+
+var errCircuitOpen = errors.New("circuit breaker open")
+
+// dialService opens a long-lived connection to a gRPC service. The connection
+// is established lazily and re-established by gRPC after failures; keepalive
+// pings detect dead peers between calls. Every call is traced and carries
+// the caller's trace context in its metadata.
+func dialService(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                30 * time.Second,
+			Timeout:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+}
+
+// breaker is a consecutive-failure circuit breaker. After breakerThreshold
+// failures it opens and calls fail fast until breakerCooldown has passed,
+// after which calls are let through again to probe the service.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Now().After(b.openUntil)
+}
+
+// record counts err against the service. Only transport failures count: an
+// application error, such as a rejected bundle, shows the service is up.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !transportFailure(err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+		b.failures = 0
+	}
+}
+
+// invoke runs a gRPC call with a per-attempt timeout, retrying with
+// exponential backoff only the calls that never reached the service: the
+// calls are not idempotent, so one that timed out may already have been
+// applied. Calls fail fast while the breaker is open.
+func invoke[T any](ctx context.Context, b *breaker, call func(context.Context) (T, error)) (T, error) {
+	var zero T
+	backoff := baseBackoff
+
+	for attempt := 1; ; attempt++ {
+		if !b.allow() {
+			return zero, errCircuitOpen
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, callTimeout)
+		resp, err := call(attemptCtx)
+		cancel()
+
+		b.record(err)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt == maxAttempts || !retryable(err) {
+			return zero, fmt.Errorf("after %d attempt(s): %w", attempt, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func retryable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+func transportFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// simulatorClient is the relay's persistent client for the SimulationService.
+type simulatorClient struct {
+	conn    *grpc.ClientConn
+	client  pb.SimulationServiceClient
+	breaker breaker
+}
+
+func newSimulatorClient(addr string) (*simulatorClient, error) {
+	conn, err := dialService(addr)
+	if err != nil {
+		return nil, fmt.Errorf("simulator client: %w", err)
+	}
+	return &simulatorClient{conn: conn, client: pb.NewSimulationServiceClient(conn)}, nil
+}
+
+func (c *simulatorClient) simulate(ctx context.Context, req *pb.BundleRequest) (*pb.BundleResponse, error) {
+	return invoke(ctx, &c.breaker, func(ctx context.Context) (*pb.BundleResponse, error) {
+		return c.client.SimulateBundle(ctx, req)
+	})
+}
+
+// builderClient is the relay's persistent client for one BuilderService.
+type builderClient struct {
+	addr    string
+	timeout time.Duration // bounds a whole submission, retries included
+	conn    *grpc.ClientConn
+	client  pb.BuilderServiceClient
+	breaker breaker
+	stats   builderStats
+}
+
+func newBuilderClient(addr string, timeout time.Duration) (*builderClient, error) {
+	conn, err := dialService(addr)
+	if err != nil {
+		return nil, fmt.Errorf("builder client %s: %w", addr, err)
+	}
+	return &builderClient{addr: addr, timeout: timeout, conn: conn, client: pb.NewBuilderServiceClient(conn)}, nil
+}
+
+func (c *builderClient) submit(ctx context.Context, req *pb.BundleSubmission) (*pb.BuildResult, error) {
+	return invoke(ctx, &c.breaker, func(ctx context.Context) (*pb.BuildResult, error) {
+		return c.client.SubmitBundle(ctx, req)
+	})
+}
+
+func (c *builderClient) cancel(ctx context.Context, req *pb.CancelRequest) (*pb.CancelResult, error) {
+	return invoke(ctx, &c.breaker, func(ctx context.Context) (*pb.CancelResult, error) {
+		return c.client.CancelBundle(ctx, req)
+	})
+}
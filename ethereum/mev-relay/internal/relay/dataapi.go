@@ -0,0 +1,205 @@
+package relay
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"mev-relay/internal/beacon"
+)
+
+const (
+	defaultBidTraceLimit = 100
+	maxBidTraceLimit     = 500
+)
+
+// BidTrace describes a block offered to, or delivered to, a proposer.
+// Builder blocks do not record the parent hash or the gas limit.
+type BidTrace struct {
+	Slot                 uint64 `json:"slot,string"`
+	ParentHash           string `json:"parent_hash,omitempty"`
+	BlockHash            string `json:"block_hash"`
+	BuilderPubkey        string `json:"builder_pubkey"`
+	ProposerPubkey       string `json:"proposer_pubkey"`
+	ProposerFeeRecipient string `json:"proposer_fee_recipient"`
+	GasLimit             uint64 `json:"gas_limit,string,omitempty"`
+	GasUsed              uint64 `json:"gas_used,string"`
+	Value                string `json:"value"` // wei, decimal string
+	BlockNumber          uint64 `json:"block_number,string"`
+	NumTx                uint64 `json:"num_tx,string"`
+}
+
+// ReceivedBidTrace is a block a builder produced, with when it was built.
+type ReceivedBidTrace struct {
+	BidTrace
+	Timestamp   int64 `json:"timestamp,string"`
+	TimestampMs int64 `json:"timestamp_ms,string"`
+}
+
+// bidTraceFilter narrows a data API query. Unset fields match everything.
+type bidTraceFilter struct {
+	Slot           *uint64
+	BlockNumber    *uint64
+	BlockHash      string
+	BuilderPubkey  string
+	ProposerPubkey string
+	Limit          int
+}
+
+// parseBidTraceFilter reads the filter from the query string.
+func parseBidTraceFilter(c *gin.Context) (bidTraceFilter, error) {
+	filter := bidTraceFilter{Limit: defaultBidTraceLimit}
+
+	for name, dst := range map[string]**uint64{"slot": &filter.Slot, "block_number": &filter.BlockNumber} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = &n
+		}
+	}
+
+	if value := c.Query("block_hash"); value != "" {
+		hash, err := hexutil.Decode(value)
+		if err != nil || len(hash) != 32 {
+			return filter, fmt.Errorf("invalid block_hash")
+		}
+		filter.BlockHash = hexutil.Encode(hash)
+	}
+	for name, dst := range map[string]*string{"builder_pubkey": &filter.BuilderPubkey, "proposer_pubkey": &filter.ProposerPubkey} {
+		if value := c.Query(name); value != "" {
+			var pubkey beacon.PublicKey
+			if err := pubkey.UnmarshalText([]byte(value)); err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = pubkey.String()
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxBidTraceLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxBidTraceLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// where returns the SQL conditions of the filter and their arguments.
+func (f bidTraceFilter) where() (string, []interface{}) {
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.Slot != nil {
+		add("slot = $%d", int64(*f.Slot))
+	}
+	if f.BlockNumber != nil {
+		add("block_number = $%d", int64(*f.BlockNumber))
+	}
+	if f.BlockHash != "" {
+		add("lower(block_hash) = $%d", f.BlockHash)
+	}
+	if f.BuilderPubkey != "" {
+		add("lower(builder_pubkey) = $%d", f.BuilderPubkey)
+	}
+	if f.ProposerPubkey != "" {
+		add("lower(proposer_pubkey) = $%d", f.ProposerPubkey)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// handlePayloadsDelivered serves GET /relay/v1/data/bidtraces/proposer_payload_delivered.
+func (s *Server) handlePayloadsDelivered(c *gin.Context) {
+	filter, err := parseBidTraceFilter(c)
+	if err != nil {
+		builderAPIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	where, args := filter.where()
+	rows, err := s.db.Query(c.Request.Context(), `
+	SELECT slot, COALESCE(parent_hash, ''), block_hash, COALESCE(builder_pubkey, ''),
+		COALESCE(proposer_pubkey, ''), COALESCE(proposer_fee_recipient, ''), COALESCE(gas_limit, 0),
+		COALESCE(gas_used, 0), COALESCE(value, 0)::text, COALESCE(block_number, 0), COALESCE(num_tx, 0)
+	FROM payloads_delivered
+	WHERE `+where+`
+	ORDER BY slot DESC, delivered_at DESC
+	LIMIT `+strconv.Itoa(filter.Limit)+`;
+	`, args...)
+	if err != nil {
+		log.Println("[Relay] Failed to query delivered payloads:", err)
+		builderAPIError(c, http.StatusInternalServerError, "failed to query delivered payloads")
+		return
+	}
+
+	traces, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (BidTrace, error) {
+		var t BidTrace
+		var slot, gasLimit, gasUsed, blockNumber, numTx int64
+		err := row.Scan(&slot, &t.ParentHash, &t.BlockHash, &t.BuilderPubkey, &t.ProposerPubkey,
+			&t.ProposerFeeRecipient, &gasLimit, &gasUsed, &t.Value, &blockNumber, &numTx)
+		t.Slot, t.GasLimit, t.GasUsed = uint64(slot), uint64(gasLimit), uint64(gasUsed)
+		t.BlockNumber, t.NumTx = uint64(blockNumber), uint64(numTx)
+		return t, err
+	})
+	if err != nil {
+		log.Println("[Relay] Failed to read delivered payloads:", err)
+		builderAPIError(c, http.StatusInternalServerError, "failed to query delivered payloads")
+		return
+	}
+	c.JSON(http.StatusOK, traces)
+}
+
+// handleBlocksReceived serves GET /relay/v1/data/bidtraces/builder_blocks_received
+// from the blocks the builders published to block_builds.
+func (s *Server) handleBlocksReceived(c *gin.Context) {
+	filter, err := parseBidTraceFilter(c)
+	if err != nil {
+		builderAPIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	where, args := filter.where()
+	rows, err := s.db.Query(c.Request.Context(), `
+	SELECT slot, block_hash, COALESCE(builder_pubkey, ''), COALESCE(proposer_pubkey, ''),
+		COALESCE(proposer_fee_recipient, ''), COALESCE(gas_used, 0), COALESCE(value, 0)::text,
+		COALESCE(block_number, 0), COALESCE(num_tx, 0), timestamp
+	FROM block_builds
+	WHERE slot IS NOT NULL AND `+where+`
+	ORDER BY slot DESC, timestamp DESC
+	LIMIT `+strconv.Itoa(filter.Limit)+`;
+	`, args...)
+	if err != nil {
+		log.Println("[Relay] Failed to query builder blocks:", err)
+		builderAPIError(c, http.StatusInternalServerError, "failed to query builder blocks")
+		return
+	}
+
+	traces, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ReceivedBidTrace, error) {
+		var t ReceivedBidTrace
+		var slot, gasUsed, blockNumber, numTx int64
+		var builtAt time.Time
+		err := row.Scan(&slot, &t.BlockHash, &t.BuilderPubkey, &t.ProposerPubkey,
+			&t.ProposerFeeRecipient, &gasUsed, &t.Value, &blockNumber, &numTx, &builtAt)
+		t.Slot, t.GasUsed, t.BlockNumber, t.NumTx = uint64(slot), uint64(gasUsed), uint64(blockNumber), uint64(numTx)
+		t.Timestamp, t.TimestampMs = builtAt.Unix(), builtAt.UnixMilli()
+		return t, err
+	})
+	if err != nil {
+		log.Println("[Relay] Failed to read builder blocks:", err)
+		builderAPIError(c, http.StatusInternalServerError, "failed to query builder blocks")
+		return
+	}
+	c.JSON(http.StatusOK, traces)
+}
@@ -0,0 +1,92 @@
+package relay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseBidTraceFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const (
+		hash   = "0x9962816e9d0a39fd4c80935338a741dc916d1545694e41eb5a505e1a3098f9e4"
+		pubkey = "0xa1dead01e65f0a0eee7b5170223f20c8f0cbf122eac3324d61afbdb33a8885ff8cab2ef514ac2c7698ae0d6289ef27fc"
+	)
+	slot, blockNumber := uint64(7), uint64(17_000_000)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    bidTraceFilter
+		wantErr string
+	}{
+		{"empty", "", bidTraceFilter{Limit: defaultBidTraceLimit}, ""},
+		{"slot and block number", "slot=7&block_number=17000000",
+			bidTraceFilter{Slot: &slot, BlockNumber: &blockNumber, Limit: defaultBidTraceLimit}, ""},
+		{"mixed-case hash and pubkeys", "block_hash=0x" + strings.ToUpper(hash[2:]) + "&builder_pubkey=0x" + strings.ToUpper(pubkey[2:]) + "&proposer_pubkey=" + pubkey + "&limit=500",
+			bidTraceFilter{BlockHash: hash, BuilderPubkey: pubkey, ProposerPubkey: pubkey, Limit: maxBidTraceLimit}, ""},
+		{"negative slot", "slot=-1", bidTraceFilter{}, "invalid slot"},
+		{"hex block number", "block_number=0x10", bidTraceFilter{}, "invalid block_number"},
+		{"short block hash", "block_hash=0x9962", bidTraceFilter{}, "invalid block_hash"},
+		{"block hash without prefix", "block_hash=" + hash[2:], bidTraceFilter{}, "invalid block_hash"},
+		{"short builder pubkey", "builder_pubkey=" + pubkey[:50], bidTraceFilter{}, "invalid builder_pubkey"},
+		{"proposer pubkey without prefix", "proposer_pubkey=" + pubkey[2:], bidTraceFilter{}, "invalid proposer_pubkey"},
+		{"zero limit", "limit=0", bidTraceFilter{}, "limit must be between 1 and 500"},
+		{"limit above maximum", "limit=501", bidTraceFilter{}, "limit must be between 1 and 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			got, err := parseBidTraceFilter(c)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBidTraceFilterWhere(t *testing.T) {
+	slot, blockNumber := uint64(7), uint64(17_000_000)
+
+	tests := []struct {
+		name     string
+		filter   bidTraceFilter
+		want     string
+		wantArgs []interface{}
+	}{
+		{"unfiltered", bidTraceFilter{}, "TRUE", nil},
+		{"slot", bidTraceFilter{Slot: &slot}, "TRUE AND slot = $1", []interface{}{int64(7)}},
+		{"every field", bidTraceFilter{Slot: &slot, BlockNumber: &blockNumber, BlockHash: "0xab", BuilderPubkey: "0xcd", ProposerPubkey: "0xef"},
+			"TRUE AND slot = $1 AND block_number = $2 AND lower(block_hash) = $3 AND lower(builder_pubkey) = $4 AND lower(proposer_pubkey) = $5",
+			[]interface{}{int64(7), int64(17_000_000), "0xab", "0xcd", "0xef"}},
+		{"placeholders follow the set fields", bidTraceFilter{BlockNumber: &blockNumber, ProposerPubkey: "0xef"},
+			"TRUE AND block_number = $1 AND lower(proposer_pubkey) = $2", []interface{}{int64(17_000_000), "0xef"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := tt.filter.where()
+			if where != tt.want {
+				t.Errorf("where = %q, want %q", where, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
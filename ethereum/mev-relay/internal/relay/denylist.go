@@ -0,0 +1,295 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Deny-list entry kinds.
+const (
+	denyAddress  = "address"  // transactions sent from or touching the address are refused
+	denySearcher = "searcher" // submissions signed by the searcher are refused
+)
+
+// DenyEntry is a denied address or searcher.
+type DenyEntry struct {
+	Kind    string         `json:"kind"`
+	Address common.Address `json:"address"`
+	Reason  string         `json:"reason"`
+	AddedAt time.Time      `json:"addedAt"`
+}
+
+type denyKey struct {
+	kind    string
+	address common.Address
+}
+
+// denyList holds the relay's deny-lists. The deny_list table is the source
+// of truth: changes are written to it before the in-memory cache, which is
+// reloaded periodically to pick up changes made by other relay instances.
+// Until the table has been read once the cache is not trusted, and every
+// submission is refused.
+type denyList struct {
+	db *pgxpool.Pool
+
+	mu      sync.RWMutex
+	entries map[denyKey]DenyEntry
+	loaded  bool
+}
+
+func newDenyList(pool *pgxpool.Pool) *denyList {
+	return &denyList{db: pool, entries: make(map[denyKey]DenyEntry)}
+}
+
+// run reloads the deny-lists every interval, after the initial load done
+// at startup. While no load has succeeded it retries every second.
+func (d *denyList) run(interval time.Duration) {
+	for {
+		wait := interval
+		if !d.ready() {
+			wait = time.Second
+		}
+		time.Sleep(wait)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := d.load(ctx); err != nil {
+			log.Println("[Relay] Failed to reload deny-lists:", err)
+		}
+		cancel()
+	}
+}
+
+// ready reports whether the deny-lists have been loaded.
+func (d *denyList) ready() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.loaded
+}
+
+// load replaces the cache with the stored entries.
+func (d *denyList) load(ctx context.Context) error {
+	rows, err := d.db.Query(ctx, `
+	SELECT kind, address, reason, added_at
+	FROM deny_list;
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	entries := make(map[denyKey]DenyEntry)
+	for rows.Next() {
+		var entry DenyEntry
+		var address string
+		if err := rows.Scan(&entry.Kind, &address, &entry.Reason, &entry.AddedAt); err != nil {
+			return err
+		}
+		entry.Address = common.HexToAddress(address)
+		entries[denyKey{entry.Kind, entry.Address}] = entry
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.entries = entries
+	d.loaded = true
+	d.mu.Unlock()
+	return nil
+}
+
+// add stores an entry, replacing the reason of an existing one, and returns
+// the stored entry.
+func (d *denyList) add(ctx context.Context, entry DenyEntry) (DenyEntry, error) {
+	_, err := d.db.Exec(ctx, `
+	INSERT INTO deny_list (kind, address, reason, added_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (kind, address) DO UPDATE SET reason = EXCLUDED.reason;
+	`, entry.Kind, entry.Address.Hex(), entry.Reason, entry.AddedAt)
+	if err != nil {
+		return DenyEntry{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if current, ok := d.entries[denyKey{entry.Kind, entry.Address}]; ok {
+		entry.AddedAt = current.AddedAt
+	}
+	d.entries[denyKey{entry.Kind, entry.Address}] = entry
+	return entry, nil
+}
+
+// remove deletes an entry and reports whether it existed.
+func (d *denyList) remove(ctx context.Context, kind string, address common.Address) (bool, error) {
+	tag, err := d.db.Exec(ctx, `
+	DELETE FROM deny_list WHERE kind = $1 AND address = $2;
+	`, kind, address.Hex())
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, cached := d.entries[denyKey{kind, address}]
+	delete(d.entries, denyKey{kind, address})
+	return tag.RowsAffected() > 0 || cached, nil
+}
+
+// list returns every entry, oldest first.
+func (d *denyList) list() []DenyEntry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	entries := make([]DenyEntry, 0, len(d.entries))
+	for _, entry := range d.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt.Before(entries[j].AddedAt)
+	})
+	return entries
+}
+
+func (d *denyList) lookup(kind string, address common.Address) (DenyEntry, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	entry, ok := d.entries[denyKey{kind, address}]
+	return entry, ok
+}
+
+// screen returns why a submission must be refused, or "" if it may go
+// through. The searcher is checked first, then the sender, recipient and
+// access list of every decoded transaction.
+func (d *denyList) screen(searcher common.Address, txs []decodedTx) string {
+	if entry, ok := d.lookup(denySearcher, searcher); ok {
+		return fmt.Sprintf("searcher %s is banned: %s", searcher.Hex(), entry.Reason)
+	}
+
+	for i, tx := range txs {
+		touched := []common.Address{tx.From}
+		if to := tx.Tx.To(); to != nil {
+			touched = append(touched, *to)
+		}
+		for _, tuple := range tx.Tx.AccessList() {
+			touched = append(touched, tuple.Address)
+		}
+
+		for _, address := range touched {
+			if entry, ok := d.lookup(denyAddress, address); ok {
+				return fmt.Sprintf("tx %d touches denied address %s: %s", i, address.Hex(), entry.Reason)
+			}
+		}
+	}
+	return ""
+}
+
+// screenBundle applies the deny-lists to decoded transactions. A refused
+// bundle is recorded with the reason and answered with errCodeDenied. While
+// the deny-lists have not been loaded every bundle is refused with
+// errCodeInternal, since none can be screened.
+func (s *Server) screenBundle(ctx context.Context, searcher common.Address, txs []decodedTx, targetBlock string) *RPCError {
+	if !s.denied.ready() {
+		log.Printf("[Relay] Refused bundle %s: deny-lists not loaded", bundleHash(txs))
+		return newRPCError(errCodeInternal, "deny-lists unavailable, try again later")
+	}
+
+	reason := s.denied.screen(searcher, txs)
+	if reason == "" {
+		return nil
+	}
+
+	hash := bundleHash(txs)
+	log.Printf("[Relay] Denied bundle %s: %s", hash, reason)
+	s.recorder.record(ctx, bundleRow{
+		BundleID:        hash,
+		Searcher:        searcher.Hex(),
+		TxCount:         len(txs),
+		TargetBlock:     targetBlock,
+		ArrivalTime:     time.Now(),
+		RejectionReason: reason,
+	})
+	return &RPCError{Code: errCodeDenied, Message: "denied", Data: gin.H{"bundleHash": hash, "reason": reason}}
+}
+
+// DenyEntryParams is the body of POST /relay/v1/admin/denylist.
+type DenyEntryParams struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+// parseDenyKey checks an entry kind and address from the admin API.
+func parseDenyKey(kind, address string) (string, common.Address, error) {
+	if kind != denyAddress && kind != denySearcher {
+		return "", common.Address{}, fmt.Errorf("kind must be %q or %q", denyAddress, denySearcher)
+	}
+	if !common.IsHexAddress(address) {
+		return "", common.Address{}, fmt.Errorf("invalid address %q", address)
+	}
+	return kind, common.HexToAddress(address), nil
+}
+
+// handleListDenied serves GET /relay/v1/admin/denylist.
+func (s *Server) handleListDenied(c *gin.Context) {
+	c.JSON(http.StatusOK, s.denied.list())
+}
+
+// handleAddDenied serves POST /relay/v1/admin/denylist.
+func (s *Server) handleAddDenied(c *gin.Context) {
+	var params DenyEntryParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+	kind, address, err := parseDenyKey(params.Kind, params.Address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a reason is required"})
+		return
+	}
+
+	entry, err := s.denied.add(c.Request.Context(), DenyEntry{Kind: kind, Address: address, Reason: params.Reason, AddedAt: time.Now()})
+	if err != nil {
+		log.Println("[Relay] Failed to store deny-list entry:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store entry"})
+		return
+	}
+
+	log.Printf("[Relay] Denied %s %s: %s", kind, address.Hex(), params.Reason)
+	c.JSON(http.StatusOK, entry)
+}
+
+// handleRemoveDenied serves DELETE /relay/v1/admin/denylist/:kind/:address.
+func (s *Server) handleRemoveDenied(c *gin.Context) {
+	kind, address, err := parseDenyKey(c.Param("kind"), c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	removed, err := s.denied.remove(c.Request.Context(), kind, address)
+	if err != nil {
+		log.Println("[Relay] Failed to remove deny-list entry:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove entry"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "entry not found"})
+		return
+	}
+
+	log.Printf("[Relay] Removed %s %s from the deny-list", kind, address.Hex())
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package relay
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestDenyListScreen(t *testing.T) {
+	var (
+		searcher       = common.HexToAddress("0x5ea5c4e4000000000000000000000000000000a1")
+		bannedSearcher = common.HexToAddress("0x5ea5c4e4000000000000000000000000000000b2")
+		sender         = common.HexToAddress("0x5e4de4000000000000000000000000000000000c")
+		recipient      = common.HexToAddress("0x4ec1b1e4700000000000000000000000000000d0")
+		denied         = common.HexToAddress("0xde41ed00000000000000000000000000000000e5")
+	)
+
+	d := newDenyList(nil)
+	d.entries[denyKey{denySearcher, bannedSearcher}] = DenyEntry{Kind: denySearcher, Address: bannedSearcher, Reason: "spam"}
+	d.entries[denyKey{denyAddress, denied}] = DenyEntry{Kind: denyAddress, Address: denied, Reason: "sanctioned"}
+	// A searcher entry does not deny the address in transactions.
+	d.entries[denyKey{denySearcher, recipient}] = DenyEntry{Kind: denySearcher, Address: recipient, Reason: "searcher only"}
+
+	tx := func(from, to common.Address, accessList ...common.Address) decodedTx {
+		var list types.AccessList
+		for _, address := range accessList {
+			list = append(list, types.AccessTuple{Address: address})
+		}
+		return decodedTx{From: from, Tx: types.NewTx(&types.DynamicFeeTx{To: &to, AccessList: list})}
+	}
+	creation := decodedTx{From: sender, Tx: types.NewTx(&types.DynamicFeeTx{})}
+
+	tests := []struct {
+		name     string
+		searcher common.Address
+		txs      []decodedTx
+		want     string // substring of the reason, "" when allowed
+	}{
+		{"clean bundle", searcher, []decodedTx{tx(sender, recipient)}, ""},
+		{"contract creation", searcher, []decodedTx{creation}, ""},
+		{"banned searcher", bannedSearcher, []decodedTx{tx(sender, recipient)}, "searcher " + bannedSearcher.Hex() + " is banned: spam"},
+		{"banned searcher with no transactions", bannedSearcher, nil, "is banned"},
+		{"denied sender", searcher, []decodedTx{tx(denied, recipient)}, "tx 0 touches denied address " + denied.Hex() + ": sanctioned"},
+		{"denied recipient", searcher, []decodedTx{tx(sender, denied)}, "tx 0 touches denied address"},
+		{"denied access list entry", searcher, []decodedTx{tx(sender, recipient, sender, denied)}, "tx 0 touches denied address"},
+		{"denied address in a later transaction", searcher, []decodedTx{tx(sender, recipient), tx(sender, denied)}, "tx 1 touches"},
+		{"searcher entry does not deny the address", searcher, []decodedTx{tx(recipient, recipient)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := d.screen(tt.searcher, tt.txs)
+			switch {
+			case tt.want == "" && got != "":
+				t.Errorf("screen refused the bundle: %s", got)
+			case tt.want != "" && !strings.Contains(got, tt.want):
+				t.Errorf("screen = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreenBundleFailsClosedUntilLoaded(t *testing.T) {
+	s := &Server{denied: newDenyList(nil)}
+	txs := []decodedTx{{Tx: types.NewTx(&types.DynamicFeeTx{})}}
+
+	rpcErr := s.screenBundle(context.Background(), common.Address{}, txs, "0x10")
+	if rpcErr == nil || rpcErr.Code != errCodeInternal {
+		t.Fatalf("screenBundle before the first load = %v, want error %d", rpcErr, errCodeInternal)
+	}
+}
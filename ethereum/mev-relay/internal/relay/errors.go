@@ -0,0 +1,65 @@
+package relay
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// JSON-RPC error codes returned by the relay. Codes -32700 to -32600 are
+// defined by the JSON-RPC 2.0 specification, the -320xx range is specific
+// to the relay:
+//
+//	-32700  parse error: the body is not valid JSON
+//	-32600  invalid request: not a JSON-RPC 2.0 request object
+//	-32601  method not found
+//	-32602  invalid params: malformed parameters or a bundle failing validation
+//	-32603  internal error: the chain node could not be queried, or the deny-lists are not loaded yet
+//	-32001  unauthorized: missing or invalid X-Flashbots-Signature
+//	-32002  simulation failed: the simulator is unreachable or could not run the bundle
+//	-32003  denied: the searcher is banned or a transaction touches a denied address
+//	-32004  not found: unknown bundle
+//	-32005  limit exceeded: the simulation queue is full
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeInternal       = -32603
+	errCodeUnauthorized   = -32001
+	errCodeSimulation     = -32002
+	errCodeDenied         = -32003
+	errCodeNotFound       = -32004
+	errCodeLimitExceeded  = -32005
+)
+
+// RPCError is a JSON-RPC error object.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func newRPCError(code int, format string, args ...interface{}) *RPCError {
+	return &RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalidParams(format string, args ...interface{}) *RPCError {
+	return newRPCError(errCodeInvalidParams, format, args...)
+}
+
+// httpStatus is the HTTP status used when the error answers a single
+// (non-batch) request. Errors about the request itself map to 4xx statuses,
+// everything else is delivered with 200 as JSON-RPC over HTTP expects.
+func (e *RPCError) httpStatus() int {
+	switch e.Code {
+	case errCodeParse, errCodeInvalidRequest:
+		return http.StatusBadRequest
+	case errCodeUnauthorized:
+		return http.StatusUnauthorized
+	case errCodeDenied:
+		return http.StatusForbidden
+	case errCodeLimitExceeded:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusOK
+}
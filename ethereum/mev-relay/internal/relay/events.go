@@ -0,0 +1,210 @@
+package relay
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"mev-relay/internal/pb"
+)
+
+// Bundle lifecycle events streamed to searchers.
+const (
+	eventReceived           = "received"
+	eventSimulated          = "simulated"
+	eventSubmittedToBuilder = "submitted_to_builder"
+	eventIncluded           = "included"
+	eventDropped            = "dropped"
+)
+
+const (
+	// eventSubscriberBuffer is how many events a slow subscriber may lag
+	// behind before events are dropped for it.
+	eventSubscriberBuffer = 256
+	// wsWriteTimeout bounds each WebSocket write.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// BundleEvent is one step in the lifecycle of a searcher's bundle.
+type BundleEvent struct {
+	Type        string    `json:"type"`
+	BundleHash  string    `json:"bundleHash"`
+	TargetBlock string    `json:"targetBlock"`
+	Timestamp   time.Time `json:"timestamp"`
+	Builder     string    `json:"builder,omitempty"`
+	BlockHash   string    `json:"blockHash,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Simulation  gin.H     `json:"simulation,omitempty"`
+}
+
+// eventBus delivers bundle events to the streams opened by the bundle's
+// searcher.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan BundleEvent]common.Address
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[chan BundleEvent]common.Address)}
+}
+
+func (b *eventBus) subscribe(searcher common.Address) chan BundleEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan BundleEvent, eventSubscriberBuffer)
+	b.subscribers[ch] = searcher
+	return ch
+}
+
+func (b *eventBus) unsubscribe(ch chan BundleEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, ch)
+}
+
+// publish sends the event to the searcher's subscribers, skipping those
+// whose buffer is full rather than blocking on them.
+func (b *eventBus) publish(searcher common.Address, event BundleEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, owner := range b.subscribers {
+		if owner != searcher {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			log.Printf("[Relay] Event subscriber of %s lagging, dropping %s event", searcher.Hex(), event.Type)
+		}
+	}
+}
+
+// emit publishes an event about the record to its searcher.
+func (s *Server) emit(record *bundleRecord, event BundleEvent) {
+	event.BundleHash = record.Hash
+	event.TargetBlock = record.TargetBlock
+	event.Timestamp = time.Now()
+	s.events.publish(record.Searcher, event)
+}
+
+// emitSimulated publishes the simulation outcome, followed by a drop event
+// when the bundle will not be forwarded to the builders.
+func (s *Server) emitSimulated(record *bundleRecord, result *pb.BundleResponse, err error) {
+	if err != nil {
+		s.emit(record, BundleEvent{Type: eventDropped, Reason: "simulation unavailable: " + err.Error()})
+		return
+	}
+
+	s.emit(record, BundleEvent{Type: eventSimulated, Simulation: simulationJSON(result, record.MatchedTxHash)})
+	if !result.Success {
+		s.emit(record, BundleEvent{Type: eventDropped, Reason: "simulation failed: " + result.Reason})
+	}
+}
+
+// emitBuild publishes a builder's answer to the bundle.
+func (s *Server) emitBuild(record *bundleRecord, outcome builderOutcome) {
+	if outcome.Err != nil {
+		return
+	}
+
+	s.emit(record, BundleEvent{
+		Type:      eventSubmittedToBuilder,
+		Builder:   outcome.Builder,
+		BlockHash: outcome.Result.BlockHash,
+		Reason:    outcome.Result.InclusionReason,
+	})
+	if outcome.Result.Included {
+		s.emit(record, BundleEvent{
+			Type:      eventIncluded,
+			Builder:   outcome.Builder,
+			BlockHash: outcome.Result.BlockHash,
+		})
+	}
+}
+
+var upgrader = websocket.Upgrader{}
+
+// handleEvents serves GET /relay/v1/events, streaming the lifecycle events
+// of the caller's bundles as server-sent events, or over a WebSocket when
+// the request asks for an upgrade.
+func (s *Server) handleEvents(c *gin.Context) {
+	ch := s.events.subscribe(searcherFrom(c))
+	defer s.events.unsubscribe(ch)
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		s.streamEventsWS(c, ch)
+		return
+	}
+
+	openEventStream(c)
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-ch:
+			c.SSEvent(event.Type, event)
+		case <-keepAlive.C:
+			_, _ = io.WriteString(w, ": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// openEventStream sends the server-sent event headers right away, so that
+// clients see the stream open before the first event.
+func openEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+func (s *Server) streamEventsWS(c *gin.Context, ch chan BundleEvent) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, http.Header{})
+	if err != nil {
+		log.Println("[Relay] WebSocket upgrade failed:", err)
+		return
+	}
+	defer conn.Close()
+
+	// Reading is needed to process control frames and notice the client
+	// closing the connection; the stream accepts no messages.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event := <-ch:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
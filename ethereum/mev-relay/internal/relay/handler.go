@@ -1,80 +1,171 @@
 package relay
 
 import (
+	"context"
+	"encoding/json"
 	"log"
-	"net/http"
-	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
-	"mev-relay/internal/config"
 	"mev-relay/internal/pb"
 )
 
-// BundleRPCRequest defines the JSON-RPC schema for eth_sendBundle.
-type BundleRPCRequest struct {
-	JSONRPC string            `json:"jsonrpc"`
-	ID      int               `json:"id"`
-	Method  string            `json:"method"`
-	Params  []BundleRPCParams `json:"params"`
-}
-
+// BundleRPCParams are the parameters of eth_sendBundle.
 type BundleRPCParams struct {
 	Txs          []string `json:"txs"`
 	BlockNumber  string   `json:"blockNumber"`
 	MinTimestamp int64    `json:"minTimestamp"`
 	MaxTimestamp int64    `json:"maxTimestamp"`
 	Replacement  *string  `json:"replacementUuid,omitempty"`
+	// RevertingTxHashes lists transactions that may revert without
+	// invalidating the bundle.
+	RevertingTxHashes []string `json:"revertingTxHashes,omitempty"`
 }
 
-// BundleRPCResponse defines the JSON-RPC response payload.
-type BundleRPCResponse struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      int         `json:"id"`
-	Result  interface{} `json:"result"`
-	Error   interface{} `json:"error,omitempty"`
-}
-
-func handleBundleRequest(c *gin.Context, cfg *config.Config) {
-	var req BundleRPCRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
-		return
+// handleSendBundle serves eth_sendBundle: the bundle is validated, queued
+// for simulation and its hash returned right away.
+func (s *Server) handleSendBundle(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var paramsList []BundleRPCParams
+	if err := json.Unmarshal(req.Params, &paramsList); err != nil || len(paramsList) == 0 {
+		return nil, invalidParams("missing params")
 	}
 
-	if len(req.Params) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing params"})
-		return
+	params := paramsList[0]
+	searcher := searcherFrom(c)
+	log.Printf("[Relay] Received bundle submission from %s with %d txs targeting block %s",
+		searcher.Hex(), len(params.Txs), params.BlockNumber)
+
+	txs, target, rpcErr := s.validateBundle(c.Request.Context(), params)
+	if rpcErr != nil {
+		log.Println("[Relay] Rejected bundle:", rpcErr.Message)
+		return nil, rpcErr
+	}
+	if rpcErr := s.screenBundle(c.Request.Context(), searcher, txs, params.BlockNumber); rpcErr != nil {
+		return nil, rpcErr
 	}
 
-	params := req.Params[0]
-	log.Printf("[Relay] Received bundle submission with %d txs targeting block %s",
-		len(params.Txs), params.BlockNumber)
+	var uuid string
+	if params.Replacement != nil {
+		uuid = *params.Replacement
+	}
 
-	result, err := dispatchToSimulator(cfg, &pb.BundleRequest{
-		BundleId:    generateBundleID(),
-		Txs:         params.Txs,
-		TargetBlock: params.BlockNumber,
+	record, rpcErr := s.enqueueBundle(c.Request.Context(), searcher, txs, target, bundleOptions{
+		TargetBlock:       params.BlockNumber,
+		ReplacementUUID:   uuid,
+		RevertingTxHashes: params.RevertingTxHashes,
+		MinTimestamp:      params.MinTimestamp,
+		MaxTimestamp:      params.MaxTimestamp,
 	})
-	if err != nil {
-		log.Println("[Relay] Simulation error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return gin.H{"bundleHash": record.Hash}, nil
+}
+
+// bundleOptions are the settings of a bundle besides its transactions.
+type bundleOptions struct {
+	TargetBlock       string
+	ReplacementUUID   string
+	RevertingTxHashes []string
+	// MinTimestamp and MaxTimestamp bound the timestamp of the block the
+	// bundle may be included in; zero leaves a bound open.
+	MinTimestamp int64
+	MaxTimestamp int64
+	// MatchedTxHash is the shared user transaction carried by the bundle,
+	// either on its own or followed by a backrun.
+	MatchedTxHash   string
+	RefundPercent   int32
+	RefundRecipient common.Address
+}
+
+// enqueueBundle registers validated transactions as a bundle for the target
+// block and queues it for simulation. A bundle already submitted for the same
+// block is not queued again; its existing record is returned instead, unless
+// the resubmission carries another replacement UUID, which the builders
+// holding the bundle would not know it by. New bundles are traced in a span
+// of their own, a child of the span in ctx.
+func (s *Server) enqueueBundle(ctx context.Context, searcher common.Address, txs []decodedTx, target uint64, opts bundleOptions) (*bundleRecord, *RPCError) {
+	hash := bundleHash(txs)
+
+	record, isNew := s.bundles.add(hash, searcher, opts)
+	if !isNew {
+		if record.ReplacementUUID != opts.ReplacementUUID {
+			return nil, invalidParams("bundle %s already submitted for block %s with replacementUuid %q",
+				hash, opts.TargetBlock, record.ReplacementUUID)
+		}
+		log.Printf("[Relay] Bundle %s already submitted for block %s", hash, opts.TargetBlock)
+		return record, nil
+	}
+
+	ctx, span := startBundleSpan(ctx, hash, searcher, opts.TargetBlock, len(txs))
+
+	raws := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		raws = append(raws, tx.Raw)
 	}
 
-	resp := BundleRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: gin.H{
-			"bundleHash":   result.BundleId,
-			"profit_eth":   result.ProfitEth,
-			"latency_ms":   result.LatencyMs,
-			"simulated_at": time.Now().UTC(),
+	priority, _ := s.reputation.tier(searcher)
+	err := s.queue.push(&bundleJob{
+		ctx:      ctx,
+		record:   record,
+		target:   target,
+		opts:     opts,
+		priority: priority,
+		request: &pb.BundleRequest{
+			BundleId:          hash,
+			Txs:               raws,
+			TargetBlock:       opts.TargetBlock,
+			Searcher:          searcher.Hex(),
+			RevertingTxHashes: opts.RevertingTxHashes,
+			MinTimestamp:      opts.MinTimestamp,
+			MaxTimestamp:      opts.MaxTimestamp,
 		},
+	})
+	if err != nil {
+		s.bundles.forget(record)
+		endSpan(span, err)
+		log.Printf("[Relay] Dropped bundle %s: %v", hash, err)
+		return nil, newRPCError(errCodeLimitExceeded, "%v", err)
 	}
+	s.recorder.record(ctx, bundleRow{
+		BundleID:    hash,
+		Searcher:    searcher.Hex(),
+		TxCount:     len(txs),
+		TargetBlock: opts.TargetBlock,
+		ArrivalTime: record.ReceivedAt,
+	})
+	s.emit(record, BundleEvent{Type: eventReceived})
 
-	c.JSON(http.StatusOK, resp)
+	if opts.ReplacementUUID != "" {
+		if previous := s.bundles.replace(record); previous != nil {
+			s.dropBundle(previous, "replaced by "+hash)
+		}
+	}
+	return record, nil
 }
 
-func generateBundleID() string {
-	return time.Now().Format("20060102T150405.000000000")
+// txResultsJSON renders per-transaction simulation results, keeping
+// zero values such as a false success flag that omitempty would drop. The
+// result of the shared user transaction matchedTxHash, if any, is reduced
+// to its hash, so that backrunning searchers learn no more than its hints.
+func txResultsJSON(results []*pb.TxResult, matchedTxHash string) []gin.H {
+	out := make([]gin.H, 0, len(results))
+	for _, r := range results {
+		if matchedTxHash != "" && r.TxHash == matchedTxHash {
+			out = append(out, gin.H{"txHash": r.TxHash})
+			continue
+		}
+		out = append(out, gin.H{
+			"txHash":            r.TxHash,
+			"gasUsed":           r.GasUsed,
+			"success":           r.Success,
+			"revert":            r.RevertReason,
+			"error":             r.Error,
+			"logs":              r.Logs,
+			"coinbaseDiff":      r.CoinbaseDiff,
+			"gasFees":           r.GasFees,
+			"ethSentToCoinbase": r.EthSentToCoinbase,
+		})
+	}
+	return out
 }
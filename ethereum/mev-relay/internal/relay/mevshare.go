@@ -0,0 +1,315 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/pb"
+)
+
+// Hints a user can reveal about a shared private transaction. The hash is
+// always revealed so that searchers can reference the transaction.
+const (
+	hintCalldata         = "calldata"
+	hintContractAddress  = "contract_address"
+	hintFunctionSelector = "function_selector"
+	hintLogs             = "logs"
+	hintHash             = "hash"
+)
+
+const (
+	// hintSubscriberBuffer is how many hints a slow stream subscriber may
+	// lag behind before hints are dropped for it.
+	hintSubscriberBuffer = 64
+	// streamKeepAlive is how often idle event streams receive a keep-alive.
+	streamKeepAlive = 15 * time.Second
+)
+
+// PrivateTxPreferences are the order flow auction preferences of a private
+// transaction.
+type PrivateTxPreferences struct {
+	Privacy PrivacyPreferences `json:"privacy"`
+}
+
+// PrivacyPreferences list the hints revealed to searchers.
+type PrivacyPreferences struct {
+	Hints []string `json:"hints,omitempty"`
+}
+
+// Hint is the partially revealed view of a shared transaction published on
+// the hint stream.
+type Hint struct {
+	Hash string    `json:"hash"`
+	Logs []HintLog `json:"logs,omitempty"`
+	Txs  []HintTx  `json:"txs,omitempty"`
+}
+
+// HintTx holds the revealed fields of the transaction.
+type HintTx struct {
+	To               string `json:"to,omitempty"`
+	FunctionSelector string `json:"functionSelector,omitempty"`
+	CallData         string `json:"callData,omitempty"`
+}
+
+// HintLog is a log emitted by the transaction in simulation.
+type HintLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// MevSendBundleParams are the parameters of mev_sendBundle.
+type MevSendBundleParams struct {
+	Version   string             `json:"version"`
+	Inclusion MevBundleInclusion `json:"inclusion"`
+	Body      []MevBundleBody    `json:"body"`
+}
+
+// MevBundleInclusion is the block range of an mev_sendBundle bundle.
+type MevBundleInclusion struct {
+	Block    string `json:"block"`
+	MaxBlock string `json:"maxBlock,omitempty"`
+}
+
+// MevBundleBody is one element of an mev_sendBundle body: either the hash
+// of a shared transaction or a signed backrun transaction.
+type MevBundleBody struct {
+	Hash      string `json:"hash,omitempty"`
+	Tx        string `json:"tx,omitempty"`
+	CanRevert bool   `json:"canRevert,omitempty"`
+}
+
+func validateHints(hints []string) *RPCError {
+	for _, hint := range hints {
+		switch hint {
+		case hintCalldata, hintContractAddress, hintFunctionSelector, hintLogs, hintHash:
+		default:
+			return invalidParams("unknown hint %q", hint)
+		}
+	}
+	return nil
+}
+
+// hintStream fans hints out to the subscribers of the SSE stream.
+type hintStream struct {
+	mu          sync.Mutex
+	subscribers map[chan Hint]struct{}
+}
+
+func newHintStream() *hintStream {
+	return &hintStream{subscribers: make(map[chan Hint]struct{})}
+}
+
+func (h *hintStream) subscribe() chan Hint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Hint, hintSubscriberBuffer)
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (h *hintStream) unsubscribe(ch chan Hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, ch)
+}
+
+// publish sends the hint to every subscriber, skipping those whose buffer
+// is full rather than blocking on them.
+func (h *hintStream) publish(hint Hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- hint:
+		default:
+			log.Println("[Relay] Hint subscriber lagging, dropping hint", hint.Hash)
+		}
+	}
+}
+
+// handleHintStream serves GET /relay/v1/mevshare/hints, a server-sent event
+// stream of the hints of shared transactions.
+func (s *Server) handleHintStream(c *gin.Context) {
+	ch := s.hints.subscribe()
+	defer s.hints.unsubscribe(ch)
+
+	openEventStream(c)
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case hint := <-ch:
+			c.SSEvent("hint", hint)
+		case <-keepAlive.C:
+			_, _ = io.WriteString(w, ": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// shareHint publishes the hints the user chose to reveal about the
+// transaction. Logs come from a simulation on top of head.
+func (s *Server) shareHint(tx *privateTx, head uint64) {
+	hint := Hint{Hash: tx.Hash.Hex()}
+
+	var revealTx bool
+	var hintTx HintTx
+	for _, kind := range tx.Hints {
+		switch kind {
+		case hintContractAddress:
+			if to := tx.Tx.Tx.To(); to != nil {
+				hintTx.To = to.Hex()
+				revealTx = true
+			}
+		case hintFunctionSelector:
+			if data := tx.Tx.Tx.Data(); len(data) >= 4 {
+				hintTx.FunctionSelector = hexutil.Encode(data[:4])
+				revealTx = true
+			}
+		case hintCalldata:
+			hintTx.CallData = hexutil.Encode(tx.Tx.Tx.Data())
+			revealTx = true
+		case hintLogs:
+			hint.Logs = s.simulatedLogs(tx, head)
+		}
+	}
+	if revealTx {
+		hint.Txs = []HintTx{hintTx}
+	}
+
+	s.hints.publish(hint)
+	log.Printf("[Relay] Published hint for private transaction %s", hint.Hash)
+}
+
+// simulatedLogs returns the logs the transaction emits when simulated for
+// the block after head, or nil if the simulation fails.
+func (s *Server) simulatedLogs(tx *privateTx, head uint64) []HintLog {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	result, err := s.simulator.simulate(ctx, &pb.BundleRequest{
+		BundleId:    tx.Hash.Hex(),
+		Txs:         []string{tx.Tx.Raw},
+		TargetBlock: hexutil.EncodeUint64(head + 1),
+		Searcher:    tx.Searcher.Hex(),
+	})
+	if err != nil || len(result.Results) == 0 {
+		log.Printf("[Relay] No logs hint for private transaction %s: simulation failed", tx.Hash.Hex())
+		return nil
+	}
+
+	var logs []HintLog
+	for _, l := range result.Results[0].Logs {
+		logs = append(logs, HintLog{Address: l.Address, Topics: l.Topics, Data: l.Data})
+	}
+	return logs
+}
+
+// handleMevSendBundle serves mev_sendBundle: a searcher backruns a shared
+// private transaction by referencing its hash. The user transaction and the
+// backrun are merged into one bundle whose backrun payment is partly
+// refunded to the user.
+func (s *Server) handleMevSendBundle(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var paramsList []MevSendBundleParams
+	if err := json.Unmarshal(req.Params, &paramsList); err != nil || len(paramsList) == 0 {
+		return nil, invalidParams("missing params")
+	}
+	params := paramsList[0]
+
+	if params.Version != "v0.1" {
+		return nil, invalidParams("unsupported version %q", params.Version)
+	}
+	target, rpcErr := s.validateTarget(c.Request.Context(), params.Inclusion.Block)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if params.Inclusion.MaxBlock != "" {
+		maxBlock, err := hexutil.DecodeUint64(params.Inclusion.MaxBlock)
+		if err != nil || maxBlock != target {
+			return nil, invalidParams("only single-block inclusion is supported")
+		}
+	}
+
+	if len(params.Body) < 2 || params.Body[0].Hash == "" {
+		return nil, invalidParams("body must be a shared transaction hash followed by backrun transactions")
+	}
+	user := s.private.get(common.HexToHash(params.Body[0].Hash))
+	if user == nil || len(user.Hints) == 0 || !user.pending() {
+		return nil, newRPCError(errCodeNotFound, "shared transaction %s not found", params.Body[0].Hash)
+	}
+	if target > user.MaxBlock {
+		return nil, invalidParams("shared transaction expires at block %d", user.MaxBlock)
+	}
+
+	raws := make([]string, 0, len(params.Body)-1)
+	for i, body := range params.Body[1:] {
+		if body.Tx == "" || body.Hash != "" {
+			return nil, invalidParams("body[%d]: expected a signed transaction", i+1)
+		}
+		raws = append(raws, body.Tx)
+	}
+	backrun, rpcErr := decodeTxs(raws, big.NewInt(s.cfg.ChainID))
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	var reverting []string
+	for i, body := range params.Body[1:] {
+		if body.CanRevert {
+			reverting = append(reverting, backrun[i].Tx.Hash().Hex())
+		}
+	}
+
+	searcher := searcherFrom(c)
+	log.Printf("[Relay] Received backrun of %s from %s targeting block %d", user.Hash.Hex(), searcher.Hex(), target)
+
+	txs := append([]decodedTx{user.Tx}, backrun...)
+	if rpcErr := s.screenBundle(c.Request.Context(), searcher, txs, params.Inclusion.Block); rpcErr != nil {
+		return nil, rpcErr
+	}
+	record, rpcErr := s.enqueueBundle(c.Request.Context(), searcher, txs, target, bundleOptions{
+		TargetBlock:       params.Inclusion.Block,
+		RevertingTxHashes: reverting,
+		MatchedTxHash:     user.Hash.Hex(),
+		RefundPercent:     int32(s.cfg.RefundPercent),
+		RefundRecipient:   user.Tx.From,
+	})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if !user.addBackrun(record) {
+		s.dropBundle(record, "shared transaction no longer pending")
+		return nil, newRPCError(errCodeNotFound, "shared transaction %s not found", user.Hash.Hex())
+	}
+
+	return gin.H{"bundleHash": record.Hash}, nil
+}
+
+// backrunValue sums what the transactions after the matched user transaction
+// pay the coinbase in the simulation.
+func backrunValue(sim *pb.BundleResponse, matchedTxHash string) *big.Int {
+	total := new(big.Int)
+	for _, tx := range sim.Results {
+		if tx.TxHash == matchedTxHash {
+			continue
+		}
+		if diff, ok := new(big.Int).SetString(tx.CoinbaseDiff, 10); ok {
+			total.Add(total, diff)
+		}
+	}
+	return total
+}
@@ -0,0 +1,109 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc"
+	"mev-relay/internal/pb"
+)
+
+// stubSimulator answers every simulation with resp, or err when set.
+type stubSimulator struct {
+	resp  *pb.BundleResponse
+	err   error
+	calls int
+}
+
+func (s *stubSimulator) SimulateBundle(ctx context.Context, in *pb.BundleRequest, opts ...grpc.CallOption) (*pb.BundleResponse, error) {
+	s.calls++
+	return s.resp, s.err
+}
+
+func TestShareHintRevealsOnlyChosenHints(t *testing.T) {
+	to := common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	calldata := []byte{0x38, 0xed, 0x17, 0x39, 0x00, 0x01, 0x02}
+	call := types.NewTx(&types.DynamicFeeTx{To: &to, Data: calldata})
+	creation := types.NewTx(&types.DynamicFeeTx{Data: calldata})
+	short := types.NewTx(&types.DynamicFeeTx{To: &to, Data: calldata[:3]})
+
+	simulated := &pb.BundleResponse{Results: []*pb.TxResult{{
+		Logs: []*pb.Log{{Address: to.Hex(), Topics: []string{"0x01"}, Data: "0x02"}},
+	}}}
+	logs := []HintLog{{Address: to.Hex(), Topics: []string{"0x01"}, Data: "0x02"}}
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		hints   []string
+		simErr  error
+		want    func(hash string) Hint
+		simCall bool
+	}{
+		{"hash only", call, []string{hintHash}, nil,
+			func(hash string) Hint { return Hint{Hash: hash} }, false},
+		{"contract address", call, []string{hintContractAddress}, nil,
+			func(hash string) Hint { return Hint{Hash: hash, Txs: []HintTx{{To: to.Hex()}}} }, false},
+		{"function selector", call, []string{hintFunctionSelector}, nil,
+			func(hash string) Hint { return Hint{Hash: hash, Txs: []HintTx{{FunctionSelector: "0x38ed1739"}}} }, false},
+		{"calldata", call, []string{hintCalldata}, nil,
+			func(hash string) Hint { return Hint{Hash: hash, Txs: []HintTx{{CallData: "0x38ed1739000102"}}} }, false},
+		{"contract address of a contract creation", creation, []string{hintContractAddress}, nil,
+			func(hash string) Hint { return Hint{Hash: hash} }, false},
+		{"function selector of short calldata", short, []string{hintFunctionSelector}, nil,
+			func(hash string) Hint { return Hint{Hash: hash} }, false},
+		{"logs", call, []string{hintLogs}, nil,
+			func(hash string) Hint { return Hint{Hash: hash, Logs: logs} }, true},
+		{"logs with failed simulation", call, []string{hintLogs}, errors.New("simulation failed"),
+			func(hash string) Hint { return Hint{Hash: hash} }, true},
+		{"every hint", call, []string{hintHash, hintContractAddress, hintFunctionSelector, hintCalldata, hintLogs}, nil,
+			func(hash string) Hint {
+				return Hint{Hash: hash, Logs: logs, Txs: []HintTx{{To: to.Hex(), FunctionSelector: "0x38ed1739", CallData: "0x38ed1739000102"}}}
+			}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := &stubSimulator{resp: simulated, err: tt.simErr}
+			s := &Server{
+				simulator: &simulatorClient{client: sim},
+				hints:     newHintStream(),
+			}
+			ch := s.hints.subscribe()
+
+			tx := &privateTx{Hash: tt.tx.Hash(), Tx: decodedTx{Tx: tt.tx}, Hints: tt.hints}
+			s.shareHint(tx, 10)
+
+			got := <-ch
+			if want := tt.want(tt.tx.Hash().Hex()); !reflect.DeepEqual(got, want) {
+				t.Errorf("hint = %+v, want %+v", got, want)
+			}
+			if simCall := sim.calls > 0; simCall != tt.simCall {
+				t.Errorf("simulated = %v, want %v", simCall, tt.simCall)
+			}
+		})
+	}
+}
+
+func TestValidateHints(t *testing.T) {
+	tests := []struct {
+		name  string
+		hints []string
+		ok    bool
+	}{
+		{"none", nil, true},
+		{"every hint", []string{hintCalldata, hintContractAddress, hintFunctionSelector, hintLogs, hintHash}, true},
+		{"unknown hint", []string{hintCalldata, "tx_value"}, false},
+		{"wrong case", []string{"Calldata"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHints(tt.hints); (err == nil) != tt.ok {
+				t.Errorf("validateHints(%q) = %v, want ok %v", tt.hints, err, tt.ok)
+			}
+		})
+	}
+}
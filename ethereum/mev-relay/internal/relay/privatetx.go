@@ -0,0 +1,440 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/pb"
+)
+
+const (
+	// defaultPrivateTxBlocks is how many blocks a private transaction
+	// without maxBlockNumber stays in the pool.
+	defaultPrivateTxBlocks = 25
+	// headPollInterval is how often the chain head is checked for new blocks.
+	headPollInterval = time.Second
+	// privateTxConfirmations is how many blocks, counting its own, must be
+	// built on a private transaction's block before it is reported included,
+	// so that a reorg does not settle a transaction that was dropped.
+	privateTxConfirmations = 3
+)
+
+// Private transaction states reported by the status API.
+const (
+	privateTxPending   = "PENDING"
+	privateTxIncluded  = "INCLUDED"
+	privateTxCancelled = "CANCELLED"
+	privateTxExpired   = "EXPIRED"
+)
+
+// PrivateTxParams are the parameters of eth_sendPrivateTransaction.
+type PrivateTxParams struct {
+	Tx             string                `json:"tx"`
+	MaxBlockNumber string                `json:"maxBlockNumber,omitempty"`
+	Preferences    *PrivateTxPreferences `json:"preferences,omitempty"`
+}
+
+// CancelPrivateTxParams are the parameters of eth_cancelPrivateTransaction.
+type CancelPrivateTxParams struct {
+	TxHash string `json:"txHash"`
+}
+
+// PrivateTxStatus describes a transaction of the private pool.
+type PrivateTxStatus struct {
+	TxHash         string        `json:"txHash"`
+	From           string        `json:"from"`
+	Searcher       string        `json:"searcher"`
+	MaxBlockNumber uint64        `json:"maxBlockNumber"`
+	ReceivedAt     time.Time     `json:"receivedAt"`
+	Status         string        `json:"status"`
+	IncludedBlock  uint64        `json:"includedBlock,omitempty"`
+	Submissions    int           `json:"submissions"`
+	Hints          []string      `json:"hints,omitempty"`
+	Backruns       int           `json:"backruns"`
+	LastBundle     *BundleStatus `json:"lastBundle,omitempty"`
+}
+
+// privateTx is a transaction kept out of the public mempool and handed to
+// the builders as a single-transaction bundle for every block.
+type privateTx struct {
+	Hash       common.Hash
+	Tx         decodedTx
+	Searcher   common.Address
+	MaxBlock   uint64
+	ReceivedAt time.Time
+	// Hints are what the user reveals about the transaction to searchers.
+	// Only transactions with hints can be backrun.
+	Hints []string
+
+	mu            sync.Mutex
+	status        string
+	settledAt     time.Time
+	includedBlock uint64
+	submissions   int
+	current       *bundleRecord
+	backruns      []*bundleRecord
+}
+
+// uuid is the replacement UUID of the transaction's bundles, so that each
+// block's bundle supersedes the previous one at the builders.
+func (p *privateTx) uuid() string {
+	return "private-tx:" + p.Hash.Hex()
+}
+
+func (p *privateTx) pending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status == privateTxPending
+}
+
+// submitted records the bundle carrying the transaction for the next block.
+func (p *privateTx) submitted(record *bundleRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current != record {
+		p.current = record
+		p.submissions++
+	}
+}
+
+// addBackrun records a bundle backrunning the transaction. It returns false
+// when the transaction is no longer pending.
+func (p *privateTx) addBackrun(record *bundleRecord) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != privateTxPending {
+		return false
+	}
+	p.backruns = append(p.backruns, record)
+	return true
+}
+
+// settle moves a pending transaction to its final state and returns its
+// backruns. It returns false when the transaction was already settled.
+func (p *privateTx) settle(status string, includedBlock uint64) ([]*bundleRecord, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != privateTxPending {
+		return nil, false
+	}
+	p.status = status
+	p.settledAt = time.Now()
+	p.includedBlock = includedBlock
+	return p.backruns, true
+}
+
+// snapshot returns the transaction's status.
+func (p *privateTx) snapshot() PrivateTxStatus {
+	p.mu.Lock()
+	st := PrivateTxStatus{
+		TxHash:         p.Hash.Hex(),
+		From:           p.Tx.From.Hex(),
+		Searcher:       p.Searcher.Hex(),
+		MaxBlockNumber: p.MaxBlock,
+		ReceivedAt:     p.ReceivedAt,
+		Status:         p.status,
+		IncludedBlock:  p.includedBlock,
+		Submissions:    p.submissions,
+		Hints:          p.Hints,
+		Backruns:       len(p.backruns),
+	}
+	current := p.current
+	p.mu.Unlock()
+
+	if current != nil {
+		bundle := current.status()
+		st.LastBundle = &bundle
+	}
+	return st
+}
+
+// privatePool holds the private transactions, pending or recently settled.
+type privatePool struct {
+	mu  sync.Mutex
+	txs map[common.Hash]*privateTx
+}
+
+func newPrivatePool() *privatePool {
+	return &privatePool{txs: make(map[common.Hash]*privateTx)}
+}
+
+// add stores a transaction, returning the existing entry and false when the
+// transaction is already pending. Settled entries are replaced.
+func (p *privatePool) add(tx *privateTx) (*privateTx, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+
+	if existing, ok := p.txs[tx.Hash]; ok && existing.pending() {
+		return existing, false
+	}
+	p.txs[tx.Hash] = tx
+	return tx, true
+}
+
+func (p *privatePool) get(hash common.Hash) *privateTx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.txs[hash]
+}
+
+// pending returns the transactions still waiting for inclusion.
+func (p *privatePool) pending() []*privateTx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+
+	txs := make([]*privateTx, 0, len(p.txs))
+	for _, tx := range p.txs {
+		if tx.pending() {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// prune drops transactions settled more than bundleRetention ago. Callers
+// must hold mu.
+func (p *privatePool) prune() {
+	cutoff := time.Now().Add(-bundleRetention)
+	for hash, tx := range p.txs {
+		tx.mu.Lock()
+		expired := tx.status != privateTxPending && tx.settledAt.Before(cutoff)
+		tx.mu.Unlock()
+		if expired {
+			delete(p.txs, hash)
+		}
+	}
+}
+
+// handleSendPrivateTx serves eth_sendPrivateTransaction: the transaction is
+// added to the private pool and queued for the next block right away.
+func (s *Server) handleSendPrivateTx(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var params []PrivateTxParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 || params[0].Tx == "" {
+		return nil, invalidParams("expected [{tx, maxBlockNumber}]")
+	}
+
+	txs, rpcErr := decodeTxs([]string{params[0].Tx}, big.NewInt(s.cfg.ChainID))
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	var hints []string
+	if params[0].Preferences != nil {
+		hints = params[0].Preferences.Privacy.Hints
+		if rpcErr := validateHints(hints); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	ctx := c.Request.Context()
+	head, err := s.eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, newRPCError(errCodeInternal, "chain head unavailable")
+	}
+
+	maxBlock := head + defaultPrivateTxBlocks
+	if params[0].MaxBlockNumber != "" {
+		maxBlock, err = hexutil.DecodeUint64(params[0].MaxBlockNumber)
+		if err != nil {
+			return nil, invalidParams("invalid maxBlockNumber %q: %v", params[0].MaxBlockNumber, err)
+		}
+		if maxBlock <= head {
+			return nil, invalidParams("maxBlockNumber %d is not in the future (head %d)", maxBlock, head)
+		}
+	}
+
+	// The transaction is first submitted for the block after head.
+	if rpcErr := s.screenBundle(ctx, searcherFrom(c), txs, hexutil.EncodeUint64(head+1)); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	tx, isNew := s.private.add(&privateTx{
+		Hash:       txs[0].Tx.Hash(),
+		Tx:         txs[0],
+		Searcher:   searcherFrom(c),
+		MaxBlock:   maxBlock,
+		ReceivedAt: time.Now(),
+		Hints:      hints,
+		status:     privateTxPending,
+	})
+	if !isNew {
+		log.Printf("[Relay] Private transaction %s already pending", tx.Hash.Hex())
+		return tx.Hash.Hex(), nil
+	}
+	log.Printf("[Relay] Received private transaction %s from %s valid until block %d",
+		tx.Hash.Hex(), tx.Searcher.Hex(), maxBlock)
+
+	if rpcErr := s.submitPrivateTx(ctx, tx, head); rpcErr != nil {
+		tx.settle(privateTxCancelled, 0)
+		return nil, rpcErr
+	}
+	if len(hints) > 0 {
+		go s.shareHint(tx, head)
+	}
+	return tx.Hash.Hex(), nil
+}
+
+// handleCancelPrivateTx serves eth_cancelPrivateTransaction. It returns
+// false when the transaction is no longer pending.
+func (s *Server) handleCancelPrivateTx(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var params []CancelPrivateTxParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+		return nil, invalidParams("expected [{txHash}]")
+	}
+	hash, err := hexutil.Decode(params[0].TxHash)
+	if err != nil || len(hash) != common.HashLength {
+		return nil, invalidParams("invalid txHash %q", params[0].TxHash)
+	}
+
+	tx := s.private.get(common.BytesToHash(hash))
+	if tx == nil || tx.Searcher != searcherFrom(c) {
+		return nil, newRPCError(errCodeNotFound, "transaction not found")
+	}
+
+	return s.settlePrivateTx(c.Request.Context(), tx, privateTxCancelled, 0), nil
+}
+
+// handlePrivateTxLookup serves GET /relay/v1/tx/:hash.
+func (s *Server) handlePrivateTxLookup(c *gin.Context) {
+	tx := s.private.get(common.HexToHash(c.Param("hash")))
+	if tx == nil || tx.Searcher != searcherFrom(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tx.snapshot())
+}
+
+// submitPrivateTx queues the transaction as a single-transaction bundle for
+// the block after head.
+func (s *Server) submitPrivateTx(ctx context.Context, tx *privateTx, head uint64) *RPCError {
+	target := head + 1
+	record, rpcErr := s.enqueueBundle(ctx, tx.Searcher, []decodedTx{tx.Tx}, target, bundleOptions{
+		TargetBlock:     hexutil.EncodeUint64(target),
+		ReplacementUUID: tx.uuid(),
+		MatchedTxHash:   tx.Hash.Hex(),
+	})
+	if rpcErr != nil {
+		return rpcErr
+	}
+	tx.submitted(record)
+	return nil
+}
+
+// settlePrivateTx finalizes a pending transaction and withdraws every
+// bundle carrying it, its own and its backruns, from the builders. Unless
+// the transaction was included, the bundles are dropped from the queue too;
+// those of an included transaction keep their outcome for status lookups.
+func (s *Server) settlePrivateTx(ctx context.Context, tx *privateTx, status string, includedBlock uint64) bool {
+	backruns, ok := tx.settle(status, includedBlock)
+	if !ok {
+		return false
+	}
+	log.Printf("[Relay] Private transaction %s %s", tx.Hash.Hex(), status)
+
+	own := s.bundles.takeUUID(tx.Searcher, tx.uuid())
+	if status != privateTxIncluded {
+		reason := "private transaction " + strings.ToLower(status)
+		if own != nil {
+			s.dropBundle(own, reason)
+		}
+		for _, record := range backruns {
+			s.dropBundle(record, reason)
+		}
+	}
+	s.cancelAtBuilders(ctx, &pb.CancelRequest{MatchedTxHash: tx.Hash.Hex()})
+	return true
+}
+
+// watchPrivateTxs follows the chain head and, on every new block, settles
+// included and expired private transactions and resubmits the others for
+// the next block. Each transaction is advanced within its own timeout, so
+// that the last ones of a large pool are not left without time.
+func (s *Server) watchPrivateTxs() {
+	ticker := time.NewTicker(headPollInterval)
+	defer ticker.Stop()
+
+	var last uint64
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+		head, err := s.eth.BlockNumber(ctx)
+		cancel()
+		if err != nil {
+			log.Println("[Relay] Failed to read chain head:", err)
+			continue
+		}
+		if head == last {
+			continue
+		}
+		last = head
+
+		for _, tx := range s.private.pending() {
+			ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+			s.advancePrivateTx(ctx, tx, head)
+			cancel()
+		}
+	}
+}
+
+// advancePrivateTx reads the transaction's receipt from the chain node. A
+// mined transaction settles once its block is confirmed and still
+// canonical; it is no longer resubmitted nor expired while it waits.
+func (s *Server) advancePrivateTx(ctx context.Context, tx *privateTx, head uint64) {
+	receipt, err := s.eth.TransactionReceipt(ctx, tx.Hash)
+	switch {
+	case err == nil:
+		s.confirmPrivateTx(ctx, tx, receipt, head)
+		return
+	case !errors.Is(err, ethereum.NotFound):
+		log.Printf("[Relay] Failed to read receipt of private transaction %s: %v", tx.Hash.Hex(), err)
+		return
+	}
+
+	if head >= tx.MaxBlock {
+		s.settlePrivateTx(ctx, tx, privateTxExpired, 0)
+		return
+	}
+
+	if rpcErr := s.submitPrivateTx(ctx, tx, head); rpcErr != nil {
+		log.Printf("[Relay] Failed to resubmit private transaction %s: %s", tx.Hash.Hex(), rpcErr.Message)
+	}
+}
+
+// confirmPrivateTx settles a mined transaction as included once
+// privateTxConfirmations blocks have been built on its block, and that
+// block is still the canonical one at its height.
+func (s *Server) confirmPrivateTx(ctx context.Context, tx *privateTx, receipt *types.Receipt, head uint64) {
+	block := receipt.BlockNumber.Uint64()
+	if head+1 < block+privateTxConfirmations {
+		return
+	}
+
+	canonical, err := s.eth.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		log.Printf("[Relay] Failed to read block %d of private transaction %s: %v", block, tx.Hash.Hex(), err)
+		return
+	}
+	if canonical.Hash() != receipt.BlockHash {
+		log.Printf("[Relay] Block %d of private transaction %s was reorged out", block, tx.Hash.Hex())
+		return
+	}
+	s.settlePrivateTx(ctx, tx, privateTxIncluded, block)
+}
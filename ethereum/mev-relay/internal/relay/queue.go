@@ -1,35 +1,255 @@
 package relay
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
-	"google.golang.org/grpc"
-	"mev-relay/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"mev-relay/internal/pb"
 )
 
-// dispatchToSimulator sends the bundle to the Simulator gRPC service
-func dispatchToSimulator(cfg *config.Config, bundle *pb.BundleRequest) (*pb.BundleResponse, error) {
-	conn, err := grpc.Dial(cfg.SimulatorPort, grpc.WithInsecure())
+var errQueueFull = errors.New("bundle queue is full")
+
+// bundleJob is a validated bundle waiting for simulation.
+type bundleJob struct {
+	// ctx carries the bundle's span, which ends once the job is processed
+	// or removed from the queue.
+	ctx     context.Context
+	record  *bundleRecord
+	request *pb.BundleRequest
+	target  uint64
+	opts    bundleOptions
+	// priority is the searcher's reputation tier; higher goes first.
+	priority int
+	seq      uint64
+}
+
+// jobHeap orders jobs by target block, then by searcher reputation, then by
+// arrival.
+type jobHeap []*bundleJob
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].target != h[j].target {
+		return h[i].target < h[j].target
+	}
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(*bundleJob)) }
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
+
+// bundleQueue is a bounded priority queue of bundles waiting for simulation.
+// Bundles for earlier target blocks are simulated first.
+type bundleQueue struct {
+	mu       sync.Mutex
+	nonEmpty *sync.Cond
+	jobs     jobHeap
+	capacity int
+	seq      uint64
+}
+
+func newBundleQueue(capacity int) *bundleQueue {
+	q := &bundleQueue{capacity: capacity}
+	q.nonEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+// push enqueues a job, failing with errQueueFull when the queue is at capacity.
+func (q *bundleQueue) push(job *bundleJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.jobs) >= q.capacity {
+		return errQueueFull
+	}
+
+	q.seq++
+	job.seq = q.seq
+	heap.Push(&q.jobs, job)
+	q.nonEmpty.Signal()
+	return nil
+}
+
+// pop blocks until a job is available and returns the highest-priority one.
+func (q *bundleQueue) pop() *bundleJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.jobs) == 0 {
+		q.nonEmpty.Wait()
+	}
+	return heap.Pop(&q.jobs).(*bundleJob)
+}
+
+// remove drops the queued job of the record and returns it, or nil if the
+// record was not queued.
+func (q *bundleQueue) remove(record *bundleRecord) *bundleJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.jobs {
+		if job.record == record {
+			heap.Remove(&q.jobs, i)
+			return job
+		}
+	}
+	return nil
+}
+
+// startWorkers launches the pool of workers that drain the queue into the simulator.
+func (s *Server) startWorkers(n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				s.processJob(s.queue.pop())
+			}
+		}()
+	}
+	log.Printf("[Relay] Started %d simulation workers", n)
+}
+
+func (s *Server) processJob(job *bundleJob) {
+	span := trace.SpanFromContext(job.ctx)
+	if job.record.cancelled() {
+		span.AddEvent("cancelled before simulation")
+		span.End()
+		return
+	}
+
+	start := time.Now()
+	result, err := s.simulator.simulate(job.ctx, job.request)
+	s.recordSimulation(job.ctx, job.record, start, result, err)
 	if err != nil {
-		return nil, err
+		log.Printf("[Relay] Simulation error for bundle %s: %v", job.record.Hash, err)
+	} else {
+		log.Printf("[Relay] Simulation result for bundle %s: profit=%.6f ETH success=%v",
+			result.BundleId, result.ProfitEth, result.Success)
+		span.SetAttributes(
+			attribute.Bool("bundle.simulation.success", result.Success),
+			attribute.Float64("bundle.simulation.profit_eth", result.ProfitEth),
+		)
 	}
-	defer conn.Close()
+	job.record.finish(result, err)
+	s.emitSimulated(job.record, result, err)
 
-	client := pb.NewSimulationServiceClient(conn)
+	if err == nil && result.Success {
+		s.forwardUnlessCancelled(job, result)
+	}
+	endSpan(span, err)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// forwardUnlessCancelled forwards the bundle to the builders unless it was
+// cancelled or replaced while it was simulated. The check and the forward
+// hold the record's forwardMu, which cancellations wait for, so a
+// cancellation never reaches the builders ahead of the bundle it cancels.
+func (s *Server) forwardUnlessCancelled(job *bundleJob, result *pb.BundleResponse) {
+	job.record.forwardMu.Lock()
+	defer job.record.forwardMu.Unlock()
 
-	resp, err := client.SimulateBundle(ctx, bundle)
+	if job.record.cancelled() {
+		trace.SpanFromContext(job.ctx).AddEvent("cancelled before forwarding")
+		return
+	}
+	s.forwardToBuilders(job, result)
+}
+
+// recordSimulation stores the simulation outcome of the searcher's bundle,
+// including transport failures, in the simulations table.
+func (s *Server) recordSimulation(ctx context.Context, record *bundleRecord, start time.Time, result *pb.BundleResponse, err error) {
+	row := simulationRow{
+		BundleID:    record.Hash,
+		Searcher:    record.Searcher.Hex(),
+		LatencyMs:   time.Since(start).Milliseconds(),
+		SimulatedAt: time.Now(),
+	}
 	if err != nil {
-		return nil, err
+		row.Reason = err.Error()
+	} else {
+		row.ProfitEth = result.ProfitEth
+		row.LatencyMs = result.LatencyMs
+		row.Success = result.Success
+		row.Reason = result.Reason
 	}
+	s.recorder.record(ctx, row)
+}
 
-	log.Printf("[Relay] Simulation result for bundle %s: profit=%.6f ETH success=%v",
-		resp.BundleId, resp.ProfitEth, resp.Success)
+// forwardToBuilders submits a successfully simulated bundle to every
+// configured builder and records each build result.
+func (s *Server) forwardToBuilders(job *bundleJob, sim *pb.BundleResponse) {
+	submission := &pb.BundleSubmission{
+		BundleId:          sim.BundleId,
+		ProfitEth:         sim.ProfitEth,
+		Txs:               job.request.Txs,
+		CoinbaseDiff:      sim.CoinbaseDiff,
+		Searcher:          job.record.Searcher.Hex(),
+		ReplacementUuid:   job.record.ReplacementUUID,
+		RevertingTxHashes: job.request.RevertingTxHashes,
+		MatchedTxHash:     job.opts.MatchedTxHash,
+		TargetBlock:       job.target,
+		GasUsed:           sim.GasUsed,
+		MinTimestamp:      job.request.MinTimestamp,
+		MaxTimestamp:      job.request.MaxTimestamp,
+	}
+	if proposer := s.validators.proposer(job.target); proposer != nil {
+		submission.ProposerFeeRecipient = proposer.FeeRecipient.Hex()
+		submission.ProposerPubkey = proposer.Pubkey.String()
+	}
+	for _, tx := range sim.Results {
+		if !tx.Success {
+			submission.RevertedTxHashes = append(submission.RevertedTxHashes, tx.TxHash)
+		}
+	}
+	if job.opts.RefundPercent > 0 {
+		submission.BackrunValue = backrunValue(sim, job.opts.MatchedTxHash).String()
+		submission.RefundPercent = job.opts.RefundPercent
+		submission.RefundRecipient = job.opts.RefundRecipient.Hex()
+	}
+
+	// Builders are called concurrently, each within its own timeout, and
+	// their answers are handled as they arrive.
+	outcomes := make(chan builderOutcome, len(s.builders))
+	for _, builder := range s.builders {
+		go func(builder *builderClient) {
+			outcomes <- builder.forward(job.ctx, submission)
+		}(builder)
+	}
 
-	return resp, nil
+	answered, included := false, false
+	for range s.builders {
+		outcome := <-outcomes
+		if outcome.Err != nil {
+			log.Printf("[Relay] Builder %s rejected bundle %s after %v: %v",
+				outcome.Builder, sim.BundleId, outcome.Latency, outcome.Err)
+		} else {
+			log.Printf("[Relay] Builder %s built block %s for bundle %s in %v (included=%v)",
+				outcome.Builder, outcome.Result.BlockHash, sim.BundleId, outcome.Latency, outcome.Result.Included)
+		}
+		job.record.addBuild(outcome)
+		s.emitBuild(job.record, outcome)
+		s.recorder.record(job.ctx, builderSubmissionRow(sim.BundleId, outcome))
+		if outcome.Err == nil {
+			answered = true
+			included = included || outcome.Result.Included
+			if b, ok := bidFromBuild(outcome.Builder, outcome.Result); ok {
+				s.bids.offer(b)
+			}
+		}
+	}
+	if answered && !included {
+		s.emit(job.record, BundleEvent{Type: eventDropped, Reason: "not selected by any builder"})
+	}
 }
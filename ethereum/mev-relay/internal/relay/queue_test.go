@@ -0,0 +1,123 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"mev-relay/internal/pb"
+)
+
+func TestBundleQueueOrder(t *testing.T) {
+	type job struct {
+		name     string
+		target   uint64
+		priority int
+	}
+	tests := []struct {
+		name string
+		push []job
+		want []string
+	}{
+		{
+			name: "earlier target first",
+			push: []job{{"b", 11, 0}, {"a", 10, 0}, {"c", 12, 0}},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "higher priority first within a target",
+			push: []job{{"low", 10, 0}, {"high", 10, 2}, {"mid", 10, 1}},
+			want: []string{"high", "mid", "low"},
+		},
+		{
+			name: "target before priority",
+			push: []job{{"late-high", 11, 5}, {"early-low", 10, 0}},
+			want: []string{"early-low", "late-high"},
+		},
+		{
+			name: "arrival order breaks ties",
+			push: []job{{"first", 10, 1}, {"second", 10, 1}, {"third", 10, 1}},
+			want: []string{"first", "second", "third"},
+		},
+		{
+			name: "mixed",
+			push: []job{{"e", 12, 0}, {"c", 11, 1}, {"a", 10, 0}, {"d", 11, 0}, {"b", 11, 1}},
+			want: []string{"a", "c", "b", "d", "e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newBundleQueue(len(tt.push))
+			names := make(map[*bundleJob]string)
+			for _, j := range tt.push {
+				job := &bundleJob{target: j.target, priority: j.priority}
+				names[job] = j.name
+				if err := q.push(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, want := range tt.want {
+				if got := names[q.pop()]; got != want {
+					t.Fatalf("pop %d = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBundleQueueBounded(t *testing.T) {
+	q := newBundleQueue(1)
+	if err := q.push(&bundleJob{target: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(&bundleJob{target: 9}); !errors.Is(err, errQueueFull) {
+		t.Fatalf("push to a full queue: err = %v, want %v", err, errQueueFull)
+	}
+}
+
+func TestBundleQueueRemove(t *testing.T) {
+	q := newBundleQueue(3)
+	records := []*bundleRecord{{Hash: "a"}, {Hash: "b"}, {Hash: "c"}}
+	for i, record := range records {
+		if err := q.push(&bundleJob{record: record, target: uint64(10 + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if job := q.remove(records[0]); job == nil || job.record != records[0] {
+		t.Fatal("remove did not return the queued job")
+	}
+	if job := q.remove(records[0]); job != nil {
+		t.Fatal("removed a job twice")
+	}
+	for _, want := range records[1:] {
+		if got := q.pop().record; got != want {
+			t.Fatalf("pop = %s, want %s", got.Hash, want.Hash)
+		}
+	}
+}
+
+func TestRecordSimulationKeepsSearcher(t *testing.T) {
+	recorder := &dbRecorder{rows: make(chan recordedRow, 2)}
+	s := &Server{recorder: recorder}
+
+	// Two searchers sending the same transactions share a bundle hash.
+	alice := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	bob := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	s.recordSimulation(context.Background(), &bundleRecord{Hash: "0x01", Searcher: alice}, time.Now(), &pb.BundleResponse{Success: true}, nil)
+	s.recordSimulation(context.Background(), &bundleRecord{Hash: "0x01", Searcher: bob}, time.Now(), nil, errors.New("simulator down"))
+
+	for _, want := range []simulationRow{
+		{BundleID: "0x01", Searcher: alice.Hex(), Success: true},
+		{BundleID: "0x01", Searcher: bob.Hex(), Reason: "simulator down"},
+	} {
+		row := (<-recorder.rows).row.(simulationRow)
+		if row.BundleID != want.BundleID || row.Searcher != want.Searcher || row.Success != want.Success || row.Reason != want.Reason {
+			t.Errorf("recorded %+v, want searcher %s success %v reason %q", row, want.Searcher, want.Success, want.Reason)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package relay
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/config"
+)
+
+// bucketIdleTTL is how long an unused token bucket is kept.
+const bucketIdleTTL = 10 * time.Minute
+
+// tokenBucket holds the request allowance of one client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter enforces token-bucket limits keyed by searcher or client IP.
+// Each take refills the bucket at the caller's tier rate, so a searcher
+// changing tier is limited at the new rate right away.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket), lastPrune: time.Now()}
+}
+
+// take spends n tokens from the key's bucket. When the bucket holds too few
+// tokens nothing is spent, and the wait until it refills enough is returned.
+func (l *rateLimiter) take(key string, tier config.RateTier, n int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > time.Minute {
+		l.prune(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(tier.Burst), last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(float64(tier.Burst), bucket.tokens+now.Sub(bucket.last).Seconds()*tier.Rate)
+	bucket.last = now
+
+	if bucket.tokens < float64(n) {
+		wait := (float64(n) - bucket.tokens) / tier.Rate
+		return false, time.Duration(wait * float64(time.Second))
+	}
+	bucket.tokens -= float64(n)
+	return true, 0
+}
+
+// prune drops buckets unused for bucketIdleTTL. Callers must hold mu.
+func (l *rateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// rateLimit charges one request to the caller: the authenticated searcher
+// when searcherAuth ran before it, the client IP otherwise.
+func (s *Server) rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.allowRequests(c, 1) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowRequests charges n requests to the caller and answers 429 with a
+// Retry-After header when its bucket is exhausted.
+func (s *Server) allowRequests(c *gin.Context, n int) bool {
+	key, tier := "ip:"+c.ClientIP(), s.reputation.lowest()
+	if searcher, ok := c.Get(searcherKey); ok {
+		addr := searcher.(common.Address)
+		key = "searcher:" + addr.Hex()
+		_, tier = s.reputation.tier(addr)
+	}
+
+	ok, wait := s.limiter.take(key, tier, n)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeRPCError(c, nullID, newRPCError(errCodeLimitExceeded, "rate limit of tier %s exceeded", tier.Name))
+	}
+	return ok
+}
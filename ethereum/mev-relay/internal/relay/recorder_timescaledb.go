@@ -0,0 +1,318 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	recorderBuffer    = 4096
+	recorderBatchSize = 100
+	recorderInterval  = time.Second
+)
+
+// dbRow is a pending insert for the recorder.
+type dbRow interface {
+	queue(batch *pgx.Batch)
+}
+
+type bundleRow struct {
+	BundleID    string
+	Searcher    string
+	TxCount     int
+	TargetBlock string
+	ArrivalTime time.Time
+	// RejectionReason is set when the bundle was refused on arrival.
+	RejectionReason string
+}
+
+func (r bundleRow) queue(batch *pgx.Batch) {
+	batch.Queue(`
+	INSERT INTO bundles (bundle_id, searcher, tx_count, target_block, arrival_time, rejection_reason)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6::text, ''));
+	`, r.BundleID, r.Searcher, r.TxCount, r.TargetBlock, r.ArrivalTime, r.RejectionReason)
+}
+
+type simulationRow struct {
+	BundleID string
+	// Searcher submitted the simulated bundle; the same bundle sent by
+	// several searchers is simulated once for each.
+	Searcher    string
+	ProfitEth   float64
+	LatencyMs   int64
+	Success     bool
+	Reason      string
+	SimulatedAt time.Time
+}
+
+func (r simulationRow) queue(batch *pgx.Batch) {
+	batch.Queue(`
+	INSERT INTO simulations (bundle_id, searcher, profit_eth, latency_ms, success, reason, simulated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, r.BundleID, r.Searcher, r.ProfitEth, r.LatencyMs, r.Success, r.Reason, r.SimulatedAt)
+}
+
+// submissionRow is one builder's answer to a forwarded bundle.
+type submissionRow struct {
+	BundleID    string
+	Builder     string
+	Accepted    bool
+	Included    bool
+	TimedOut    bool
+	LatencyMs   int64
+	BlockHash   string
+	Error       string
+	SubmittedAt time.Time
+}
+
+func builderSubmissionRow(bundleID string, outcome builderOutcome) submissionRow {
+	row := submissionRow{
+		BundleID:    bundleID,
+		Builder:     outcome.Builder,
+		Accepted:    accepted(outcome),
+		LatencyMs:   outcome.Latency.Milliseconds(),
+		SubmittedAt: outcome.At,
+	}
+	if outcome.Err != nil {
+		row.TimedOut = timedOut(outcome.Err)
+		row.Error = outcome.Err.Error()
+	} else {
+		row.Included = outcome.Result.Included
+		row.BlockHash = outcome.Result.BlockHash
+	}
+	return row
+}
+
+func (r submissionRow) queue(batch *pgx.Batch) {
+	batch.Queue(`
+	INSERT INTO builder_submissions (bundle_id, builder, accepted, included, timed_out, latency_ms, block_hash, error, submitted_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`, r.BundleID, r.Builder, r.Accepted, r.Included, r.TimedOut, r.LatencyMs, r.BlockHash, r.Error, r.SubmittedAt)
+}
+
+// registrationRow upserts a validator's registration, keeping the newest.
+// Registrations are written synchronously rather than through the recorder.
+type registrationRow struct {
+	Pubkey       string
+	FeeRecipient string
+	GasLimit     uint64
+	Timestamp    uint64
+	Signature    string
+	RegisteredAt time.Time
+}
+
+func (r registrationRow) queue(batch *pgx.Batch) {
+	batch.Queue(`
+	INSERT INTO validator_registrations (pubkey, fee_recipient, gas_limit, timestamp, signature, registered_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (pubkey) DO UPDATE
+	SET fee_recipient = EXCLUDED.fee_recipient, gas_limit = EXCLUDED.gas_limit,
+		timestamp = EXCLUDED.timestamp, signature = EXCLUDED.signature, registered_at = EXCLUDED.registered_at
+	WHERE validator_registrations.timestamp < EXCLUDED.timestamp;
+	`, r.Pubkey, r.FeeRecipient, int64(r.GasLimit), int64(r.Timestamp), r.Signature, r.RegisteredAt)
+}
+
+// payloadDeliveredRow is a payload revealed to the slot's proposer.
+type payloadDeliveredRow struct {
+	BidTrace
+	DeliveredAt time.Time
+}
+
+func deliveredRow(served *servedBid) payloadDeliveredRow {
+	payload := served.Payload
+	return payloadDeliveredRow{
+		BidTrace: BidTrace{
+			Slot:                 payload.BlockNumber,
+			ParentHash:           payload.ParentHash.Hex(),
+			BlockHash:            payload.BlockHash.Hex(),
+			BuilderPubkey:        served.Bid.BuilderPubkey,
+			ProposerPubkey:       served.Proposer.String(),
+			ProposerFeeRecipient: payload.FeeRecipient.Hex(),
+			GasLimit:             payload.GasLimit,
+			GasUsed:              payload.GasUsed,
+			Value:                served.Bid.Value.String(),
+			BlockNumber:          payload.BlockNumber,
+			NumTx:                uint64(len(payload.Transactions)),
+		},
+		DeliveredAt: served.DeliveredAt,
+	}
+}
+
+func (r payloadDeliveredRow) queue(batch *pgx.Batch) {
+	batch.Queue(`
+	INSERT INTO payloads_delivered (slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey,
+		proposer_fee_recipient, gas_limit, gas_used, value, block_number, num_tx, delivered_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::text::numeric, $10, $11, $12);
+	`, int64(r.Slot), r.ParentHash, r.BlockHash, r.BuilderPubkey, r.ProposerPubkey,
+		r.ProposerFeeRecipient, int64(r.GasLimit), int64(r.GasUsed), r.Value, int64(r.BlockNumber), int64(r.NumTx), r.DeliveredAt)
+}
+
+// dbRecorder writes relay rows into TimescaleDB in batches from a background
+// goroutine, so database latency never sits on the request path.
+type dbRecorder struct {
+	db   *pgxpool.Pool
+	rows chan recordedRow
+	stop chan struct{}
+	done chan struct{}
+}
+
+// recordedRow is a queued row and the span of the operation that recorded
+// it, which the batch inserting it links to.
+type recordedRow struct {
+	row  dbRow
+	link trace.Link
+}
+
+// newDBRecorder creates a recorder and starts its flush loop.
+func newDBRecorder(pool *pgxpool.Pool) *dbRecorder {
+	r := &dbRecorder{
+		db:   pool,
+		rows: make(chan recordedRow, recorderBuffer),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// record queues a row for insertion on behalf of the operation traced in
+// ctx. Rows are dropped when the buffer is full rather than blocking the
+// caller.
+func (r *dbRecorder) record(ctx context.Context, row dbRow) {
+	select {
+	case r.rows <- recordedRow{row: row, link: trace.LinkFromContext(ctx)}:
+	default:
+		log.Printf("[Recorder] Buffer full, dropping %T", row)
+	}
+}
+
+// close flushes the rows queued so far and stops the recorder. Rows
+// recorded afterwards are not stored. It gives up when ctx is done.
+func (r *dbRecorder) close(ctx context.Context) error {
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *dbRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(recorderInterval)
+	defer ticker.Stop()
+
+	pending := make([]recordedRow, 0, recorderBatchSize)
+	for {
+		select {
+		case row := <-r.rows:
+			pending = append(pending, row)
+			if len(pending) < recorderBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+		case <-r.stop:
+			r.drain(pending)
+			return
+		}
+
+		r.flush(pending)
+		pending = pending[:0]
+	}
+}
+
+// drain flushes pending and every row still buffered.
+func (r *dbRecorder) drain(pending []recordedRow) {
+	for {
+		select {
+		case row := <-r.rows:
+			pending = append(pending, row)
+			if len(pending) < recorderBatchSize {
+				continue
+			}
+		default:
+			if len(pending) > 0 {
+				r.flush(pending)
+			}
+			return
+		}
+		r.flush(pending)
+		pending = pending[:0]
+	}
+}
+
+// flush inserts rows in one batch. The batch gets a span of its own, linked
+// to the spans of the operations that recorded the rows, so that a bundle's
+// trace leads to the insert of its rows. The batch runs in one implicit
+// transaction, so a row the database rejects rolls the others back; they are
+// then inserted one by one so that only the bad row is lost.
+func (r *dbRecorder) flush(rows []recordedRow) {
+	batch := &pgx.Batch{}
+	var links []trace.Link
+	for _, row := range rows {
+		row.row.queue(batch)
+		if row.link.SpanContext.IsValid() {
+			links = append(links, row.link)
+		}
+	}
+
+	ctx, span := tracer.Start(context.Background(), "recorder flush",
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("recorder.rows", len(rows))),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stored := len(rows)
+	err := r.db.SendBatch(ctx, batch).Close()
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(rows) > 1 {
+		span.AddEvent("retrying rows one by one", trace.WithAttributes(attribute.String("error", err.Error())))
+		stored, err = r.flushEach(ctx, rows)
+	}
+	endSpan(span, err)
+	if err != nil {
+		log.Printf("[Recorder] Failed to insert %d rows: %v", len(rows), err)
+		return
+	}
+	log.Printf("[Recorder] Stored %d rows in TimescaleDB", stored)
+}
+
+// flushEach inserts rows one at a time, logging and skipping those the
+// database rejects, and returns how many were stored. It fails when the
+// database cannot be reached or rejects every row.
+func (r *dbRecorder) flushEach(ctx context.Context, rows []recordedRow) (int, error) {
+	stored := 0
+	for _, row := range rows {
+		batch := &pgx.Batch{}
+		row.row.queue(batch)
+		err := r.db.SendBatch(ctx, batch).Close()
+		var pgErr *pgconn.PgError
+		switch {
+		case err == nil:
+			stored++
+		case errors.As(err, &pgErr):
+			log.Printf("[Recorder] Dropping %T rejected by the database: %v", row.row, err)
+		default:
+			return stored, err
+		}
+	}
+	if stored == 0 {
+		return 0, fmt.Errorf("all %d rows rejected", len(rows))
+	}
+	return stored, nil
+}
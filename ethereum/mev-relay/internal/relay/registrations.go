@@ -0,0 +1,194 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/internal/beacon"
+)
+
+// errStoringRegistrations is returned when accepted registrations could not
+// be written to the database.
+var errStoringRegistrations = errors.New("storing registrations failed")
+
+// maxRegistrationDrift is how far in the future a registration timestamp may
+// be, to tolerate clock skew between the validator and the relay.
+const maxRegistrationDrift = 10 * time.Second
+
+// validatorRegistry holds the latest signed registration of each validator.
+// Registrations are verified against the builder domain, persisted to the
+// validator_registrations table and reloaded from it on startup.
+type validatorRegistry struct {
+	db     *pgxpool.Pool
+	domain beacon.Domain
+
+	mu            sync.RWMutex
+	registrations map[beacon.PublicKey]*beacon.SignedValidatorRegistration
+	pubkeys       []beacon.PublicKey
+}
+
+func newValidatorRegistry(pool *pgxpool.Pool, domain beacon.Domain) *validatorRegistry {
+	return &validatorRegistry{
+		db:            pool,
+		domain:        domain,
+		registrations: make(map[beacon.PublicKey]*beacon.SignedValidatorRegistration),
+	}
+}
+
+// verify checks a registration's timestamp and BLS signature.
+func (r *validatorRegistry) verify(registration *beacon.SignedValidatorRegistration) error {
+	message := registration.Message
+	if message.Timestamp > uint64(time.Now().Add(maxRegistrationDrift).Unix()) {
+		return fmt.Errorf("timestamp %d is in the future", message.Timestamp)
+	}
+	root := beacon.SigningRoot(message.HashTreeRoot(), r.domain)
+	if !beacon.Verify(message.Pubkey, root[:], registration.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// register verifies and stores a batch of registrations. The batch is
+// rejected as a whole if any registration is invalid. Registrations older
+// than, or identical to, a validator's stored one are ignored. The batch is
+// written to the database before it is kept in memory, so that every
+// acknowledged registration survives a restart; a failed write fails with
+// errStoringRegistrations.
+func (r *validatorRegistry) register(ctx context.Context, signed []*beacon.SignedValidatorRegistration) error {
+	updates := make([]*beacon.SignedValidatorRegistration, 0, len(signed))
+	for i, registration := range signed {
+		if current := r.get(registration.Message.Pubkey); current != nil &&
+			current.Timestamp >= registration.Message.Timestamp {
+			continue
+		}
+		if err := r.verify(registration); err != nil {
+			return fmt.Errorf("registration %d (%s): %w", i, registration.Message.Pubkey, err)
+		}
+		updates = append(updates, registration)
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := r.save(ctx, updates); err != nil {
+		return fmt.Errorf("%w: %v", errStoringRegistrations, err)
+	}
+
+	r.mu.Lock()
+	for _, registration := range updates {
+		r.store(registration)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// save upserts the registrations in one batch.
+func (r *validatorRegistry) save(ctx context.Context, registrations []*beacon.SignedValidatorRegistration) error {
+	batch := &pgx.Batch{}
+	now := time.Now()
+	for _, registration := range registrations {
+		registrationRow{
+			Pubkey:       registration.Message.Pubkey.String(),
+			FeeRecipient: registration.Message.FeeRecipient.Hex(),
+			GasLimit:     registration.Message.GasLimit,
+			Timestamp:    registration.Message.Timestamp,
+			Signature:    hexutil.Encode(registration.Signature[:]),
+			RegisteredAt: now,
+		}.queue(batch)
+	}
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+// store keeps the registration unless a newer one is already stored.
+// Callers must hold mu.
+func (r *validatorRegistry) store(registration *beacon.SignedValidatorRegistration) {
+	pubkey := registration.Message.Pubkey
+	current, ok := r.registrations[pubkey]
+	if ok && current.Message.Timestamp >= registration.Message.Timestamp {
+		return
+	}
+	if !ok {
+		r.pubkeys = append(r.pubkeys, pubkey)
+	}
+	r.registrations[pubkey] = registration
+}
+
+// get returns the validator's registration, or nil if it never registered.
+func (r *validatorRegistry) get(pubkey beacon.PublicKey) *beacon.ValidatorRegistration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if registration, ok := r.registrations[pubkey]; ok {
+		return registration.Message
+	}
+	return nil
+}
+
+// proposer returns the registration of the slot's proposer, or nil when no
+// validator has registered. Builders pay the block's proposer payment to its
+// fee recipient.
+func (r *validatorRegistry) proposer(slot uint64) *beacon.ValidatorRegistration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.pubkeys) == 0 {
+		return nil
+	}
+	return r.registrations[beacon.DevnetProposer(slot, r.pubkeys)].Message
+}
+
+// load reads the stored registrations. Signatures were verified when the
+// registrations were accepted and are not checked again.
+func (r *validatorRegistry) load(ctx context.Context) error {
+	rows, err := r.db.Query(ctx, `
+	SELECT pubkey, fee_recipient, gas_limit, timestamp, signature
+	FROM validator_registrations;
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded := 0
+	for rows.Next() {
+		var pubkey, feeRecipient, signature string
+		var gasLimit, timestamp int64
+		if err := rows.Scan(&pubkey, &feeRecipient, &gasLimit, &timestamp, &signature); err != nil {
+			return err
+		}
+
+		message := &beacon.ValidatorRegistration{
+			FeeRecipient: common.HexToAddress(feeRecipient),
+			GasLimit:     uint64(gasLimit),
+			Timestamp:    uint64(timestamp),
+		}
+		registration := &beacon.SignedValidatorRegistration{Message: message}
+		if err := message.Pubkey.UnmarshalText([]byte(pubkey)); err != nil {
+			log.Printf("[Relay] Skipping stored registration of %s: %v", pubkey, err)
+			continue
+		}
+		if err := registration.Signature.UnmarshalText([]byte(signature)); err != nil {
+			log.Printf("[Relay] Skipping stored registration of %s: %v", pubkey, err)
+			continue
+		}
+		r.store(registration)
+		loaded++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("[Relay] Loaded %d validator registrations", loaded)
+	return nil
+}
@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/internal/config"
+)
+
+// reputationWindow is how far back simulation results count towards a
+// searcher's reputation.
+const reputationWindow = 7 * 24 * time.Hour
+
+// reputationBook assigns searchers a rate tier from the success rate of
+// their recent simulations. Searchers with too few simulations, and
+// unauthenticated clients, get the lowest tier.
+type reputationBook struct {
+	db             *pgxpool.Pool
+	tiers          []config.RateTier
+	minSimulations int
+
+	mu     sync.RWMutex
+	levels map[common.Address]int
+}
+
+func newReputationBook(pool *pgxpool.Pool, cfg *config.Config) *reputationBook {
+	return &reputationBook{
+		db:             pool,
+		tiers:          cfg.RateTiers,
+		minSimulations: cfg.ReputationMinSimulations,
+		levels:         make(map[common.Address]int),
+	}
+}
+
+// tier returns the searcher's tier and its index; higher indexes rank higher.
+func (r *reputationBook) tier(searcher common.Address) (int, config.RateTier) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	level := r.levels[searcher]
+	return level, r.tiers[level]
+}
+
+// lowest returns the tier of clients without a reputation.
+func (r *reputationBook) lowest() config.RateTier {
+	return r.tiers[0]
+}
+
+// run refreshes the reputations every interval.
+func (r *reputationBook) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.refresh(); err != nil {
+			log.Println("[Relay] Failed to refresh searcher reputations:", err)
+		}
+		<-ticker.C
+	}
+}
+
+// refresh recomputes every searcher's success rate from the simulations
+// table, which records the searcher each bundle was simulated for.
+func (r *reputationBook) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	since := time.Now().Add(-reputationWindow)
+	rows, err := r.db.Query(ctx, `
+	SELECT searcher, COUNT(*), COUNT(*) FILTER (WHERE success)
+	FROM simulations
+	WHERE simulated_at > $1 AND searcher IS NOT NULL
+	GROUP BY searcher;
+	`, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	levels := make(map[common.Address]int)
+	for rows.Next() {
+		var searcher string
+		var total, succeeded int
+		if err := rows.Scan(&searcher, &total, &succeeded); err != nil {
+			return err
+		}
+		if total < r.minSimulations || !common.IsHexAddress(searcher) {
+			continue
+		}
+
+		rate := float64(succeeded) / float64(total)
+		for i, tier := range r.tiers {
+			if rate >= tier.MinSuccessRate {
+				levels[common.HexToAddress(searcher)] = i
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.levels = levels
+	r.mu.Unlock()
+
+	log.Printf("[Relay] Refreshed reputation of %d searchers", len(levels))
+	return nil
+}
@@ -0,0 +1,178 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BundleRPCRequest defines the JSON-RPC request envelope. Params are decoded
+// by the handler of the requested method. ID is kept raw so that string and
+// numeric IDs are echoed back unchanged; it is nil for notifications.
+type BundleRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// BundleRPCResponse defines the JSON-RPC response payload.
+type BundleRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// MarshalJSON writes exactly one of result and error, as JSON-RPC 2.0
+// requires: a successful call whose handler returned no result answers
+// "result": null, and a failed one carries no result.
+func (r BundleRPCResponse) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *RPCError       `json:"error"`
+		}{r.JSONRPC, r.ID, r.Error})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result"`
+	}{r.JSONRPC, r.ID, r.Result})
+}
+
+// rpcHandler serves one JSON-RPC method, returning either a result or an error.
+type rpcHandler func(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError)
+
+var nullID = json.RawMessage("null")
+
+// rpcMethods maps the JSON-RPC methods served by the relay to their handlers.
+func (s *Server) rpcMethods() map[string]rpcHandler {
+	return map[string]rpcHandler{
+		"eth_sendBundle":               s.handleSendBundle,
+		"eth_callBundle":               s.handleCallBundle,
+		"eth_cancelBundle":             s.handleCancelBundle,
+		"flashbots_getBundleStatsV2":   s.handleBundleStats,
+		"eth_sendPrivateTransaction":   s.handleSendPrivateTx,
+		"eth_cancelPrivateTransaction": s.handleCancelPrivateTx,
+		"mev_sendBundle":               s.handleMevSendBundle,
+	}
+}
+
+// handleRPC serves the relay's JSON-RPC endpoint, accepting a single request
+// or a batch. Notifications are executed but get no response.
+func (s *Server) handleRPC(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil || !json.Valid(body) {
+		writeRPCError(c, nullID, newRPCError(errCodeParse, "invalid JSON"))
+		return
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		s.handleBatch(c, body)
+		return
+	}
+
+	resp, ok := s.dispatch(c, body)
+	if !ok {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Error != nil {
+		status = resp.Error.httpStatus()
+	}
+	c.JSON(status, resp)
+}
+
+func (s *Server) handleBatch(c *gin.Context, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeRPCError(c, nullID, newRPCError(errCodeParse, "invalid JSON"))
+		return
+	}
+	if len(batch) == 0 {
+		writeRPCError(c, nullID, newRPCError(errCodeInvalidRequest, "empty batch"))
+		return
+	}
+	// The rate limiter charged the HTTP request; charge the other calls too.
+	if len(batch) > 1 && !s.allowRequests(c, len(batch)-1) {
+		return
+	}
+
+	responses := make([]BundleRPCResponse, 0, len(batch))
+	for _, raw := range batch {
+		if resp, ok := s.dispatch(c, raw); ok {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// dispatch validates one request object and runs its method. It reports
+// false for notifications, which must not be answered.
+func (s *Server) dispatch(c *gin.Context, raw json.RawMessage) (BundleRPCResponse, bool) {
+	var req BundleRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(nullID, newRPCError(errCodeInvalidRequest, "request must be an object")), true
+	}
+
+	if !validID(req.ID) {
+		return errorResponse(nullID, newRPCError(errCodeInvalidRequest, "id must be a string, number or null")), true
+	}
+	id := req.ID
+	if id == nil {
+		id = nullID
+	}
+
+	if req.JSONRPC != "2.0" {
+		return errorResponse(id, newRPCError(errCodeInvalidRequest, `jsonrpc must be "2.0"`)), req.ID != nil
+	}
+
+	handler, ok := s.methods[req.Method]
+	if !ok {
+		return errorResponse(id, newRPCError(errCodeMethodNotFound, "method not found: %s", req.Method)), req.ID != nil
+	}
+
+	result, rpcErr := traceRPC(c, req.Method, func() (interface{}, *RPCError) {
+		return handler(c, req)
+	})
+	if rpcErr != nil {
+		return errorResponse(id, rpcErr), req.ID != nil
+	}
+	return BundleRPCResponse{JSONRPC: "2.0", ID: id, Result: result}, req.ID != nil
+}
+
+// validID accepts absent, null, string and numeric IDs.
+func validID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
+
+func errorResponse(id json.RawMessage, rpcErr *RPCError) BundleRPCResponse {
+	return BundleRPCResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+func writeRPCError(c *gin.Context, id json.RawMessage, rpcErr *RPCError) {
+	c.JSON(rpcErr.httpStatus(), errorResponse(id, rpcErr))
+}
@@ -0,0 +1,50 @@
+package relay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleRPCResponseHasResultOrError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{methods: map[string]rpcHandler{
+		"test_nil":   func(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) { return nil, nil },
+		"test_false": func(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) { return false, nil },
+		"test_hash": func(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+			return gin.H{"bundleHash": "0x01"}, nil
+		},
+		"test_error": func(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+			return nil, invalidParams("bad params")
+		},
+	}}
+	router := gin.New()
+	router.POST("/", s.handleRPC)
+
+	tests := []struct {
+		name   string
+		method string
+		want   string
+	}{
+		{"nil result", "test_nil", `{"jsonrpc":"2.0","id":1,"result":null}`},
+		{"false result", "test_false", `{"jsonrpc":"2.0","id":1,"result":false}`},
+		{"object result", "test_hash", `{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x01"}}`},
+		{"error", "test_error", `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"bad params"}}`},
+		{"unknown method", "test_missing", `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: test_missing"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"jsonrpc":"2.0","id":1,"method":"` + tt.method + `","params":[]}`
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("response = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,22 +1,203 @@
 package relay
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mev-relay/internal/beacon"
 	"mev-relay/internal/config"
+	"mev-relay/internal/tracing"
 )
 
+// Server holds the relay state shared between requests.
+type Server struct {
+	cfg        *config.Config
+	db         *pgxpool.Pool
+	eth        *ethclient.Client
+	simulator  *simulatorClient
+	builders   []*builderClient
+	bundles    *bundleStore
+	queue      *bundleQueue
+	private    *privatePool
+	hints      *hintStream
+	limiter    *rateLimiter
+	reputation *reputationBook
+	events     *eventBus
+	denied     *denyList
+	recorder   *dbRecorder
+	methods    map[string]rpcHandler
+
+	blsKey         *beacon.SecretKey
+	builderDomain  beacon.Domain
+	proposerDomain beacon.Domain
+	validators     *validatorRegistry
+	bids           *bidStore
+}
+
+// NewServer creates a relay server for the given configuration.
+func NewServer(cfg *config.Config, pool *pgxpool.Pool) (*Server, error) {
+	// Node calls are traced over HTTP; the node itself ignores the headers.
+	rpcClient, err := rpc.DialOptions(context.Background(), cfg.GethRPC, rpc.WithHTTPClient(tracing.HTTPClient()))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to node: %w", err)
+	}
+	eth := ethclient.NewClient(rpcClient)
+
+	simulator, err := newSimulatorClient(cfg.SimulatorAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	builders := make([]*builderClient, 0, len(cfg.Builders))
+	for _, endpoint := range cfg.Builders {
+		builder, err := newBuilderClient(endpoint.Addr, time.Duration(endpoint.TimeoutMs)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, builder)
+	}
+
+	blsKey, err := relaySecretKey(cfg.RelaySecretKey)
+	if err != nil {
+		return nil, err
+	}
+	forkVersion, err := beacon.ParseForkVersion(cfg.GenesisForkVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	builderDomain := beacon.BuilderDomain(forkVersion)
+	recorder := newDBRecorder(pool)
+	s := &Server{
+		cfg:        cfg,
+		db:         pool,
+		eth:        eth,
+		simulator:  simulator,
+		builders:   builders,
+		bundles:    newBundleStore(),
+		queue:      newBundleQueue(cfg.QueueSize),
+		private:    newPrivatePool(),
+		hints:      newHintStream(),
+		limiter:    newRateLimiter(),
+		reputation: newReputationBook(pool, cfg),
+		events:     newEventBus(),
+		denied:     newDenyList(pool),
+		recorder:   recorder,
+
+		blsKey:         blsKey,
+		builderDomain:  builderDomain,
+		proposerDomain: beacon.ComputeDomain(beacon.DomainTypeBeaconProposer, forkVersion, common.Hash{}),
+		validators:     newValidatorRegistry(pool, builderDomain),
+		bids:           newBidStore(),
+	}
+	s.methods = s.rpcMethods()
+	return s, nil
+}
+
+// relaySecretKey parses the relay's bid signing key, generating a throwaway
+// one when none is configured.
+func relaySecretKey(hexKey string) (*beacon.SecretKey, error) {
+	if hexKey == "" {
+		key, err := beacon.GenerateSecretKey()
+		if err != nil {
+			return nil, fmt.Errorf("generating relay key: %w", err)
+		}
+		log.Printf("[Relay] RELAY_SECRET_KEY not set, signing bids with generated key %s", key.PublicKey())
+		return key, nil
+	}
+
+	key, err := beacon.SecretKeyFromHex(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RELAY_SECRET_KEY: %w", err)
+	}
+	return key, nil
+}
+
 // StartServer launches the JSON-RPC relay service that receives bundles from searchers.
-func StartServer(cfg *config.Config) error {
+func StartServer(cfg *config.Config, pool *pgxpool.Pool) error {
+	s, err := NewServer(cfg, pool)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := s.validators.load(ctx); err != nil {
+		log.Println("[Relay] Failed to load validator registrations:", err)
+	}
+	if err := s.denied.load(ctx); err != nil {
+		log.Println("[Relay] Failed to load deny-lists, refusing submissions until they load:", err)
+	}
+	cancel()
+
+	s.startWorkers(s.cfg.QueueWorkers)
+	go s.watchPrivateTxs()
+	go s.reputation.run(time.Duration(cfg.ReputationRefreshSecs) * time.Second)
+	go s.denied.run(time.Duration(cfg.DenyListRefreshSecs) * time.Second)
+
 	router := gin.Default()
+	router.Use(traceRequests())
+
+	router.POST("/relay/v1/bundle", searcherAuth(), s.rateLimit(), s.handleRPC)
+	router.GET("/relay/v1/bundle/:hash", searcherAuth(), s.rateLimit(), s.handleBundleLookup)
+	router.GET("/relay/v1/tx/:hash", searcherAuth(), s.rateLimit(), s.handlePrivateTxLookup)
+	router.GET("/relay/v1/events", searcherAuth(), s.rateLimit(), s.handleEvents)
+	router.GET("/relay/v1/mevshare/hints", s.rateLimit(), s.handleHintStream)
+
+	router.GET("/eth/v1/builder/status", s.handleBuilderStatus)
+	router.POST("/eth/v1/builder/validators", s.handleRegisterValidators)
+	router.GET("/eth/v1/builder/header/:slot/:parent_hash/:pubkey", s.handleGetHeader)
+	router.POST("/eth/v1/builder/blinded_blocks", s.handleGetPayload)
+
+	router.GET("/relay/v1/data/bidtraces/proposer_payload_delivered", s.rateLimit(), s.handlePayloadsDelivered)
+	router.GET("/relay/v1/data/bidtraces/builder_blocks_received", s.rateLimit(), s.handleBlocksReceived)
+	router.GET("/relay/v1/builders", s.rateLimit(), s.handleBuilderStats)
 
-	router.POST("/relay/v1/bundle", func(c *gin.Context) {
-		handleBundleRequest(c, cfg)
-	})
+	admin := router.Group("/relay/v1/admin", adminAuth(cfg.AdminToken))
+	admin.GET("/denylist", s.handleListDenied)
+	admin.POST("/denylist", s.handleAddDenied)
+	admin.DELETE("/denylist/:kind/:address", s.handleRemoveDenied)
 
 	addr := ":" + cfg.RelayPort
 	log.Println("[Relay] Listening on", addr)
 
-	return router.Run(addr)
+	return serveUntilSignal(&http.Server{Addr: addr, Handler: router}, s.recorder)
+}
+
+// serveUntilSignal serves until SIGINT or SIGTERM, then stops accepting
+// requests, lets the ongoing ones finish and flushes the recorder, so that
+// a restart does not lose the rows still buffered.
+func serveUntilSignal(srv *http.Server, recorder *dbRecorder) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("[Relay] Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("[Relay] Failed to drain requests:", err)
+	}
+	if err := recorder.close(shutdownCtx); err != nil {
+		return fmt.Errorf("flushing recorder: %w", err)
+	}
+	return nil
 }
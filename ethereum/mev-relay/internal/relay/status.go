@@ -0,0 +1,150 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mev-relay/internal/pb"
+)
+
+// BundleStatus describes what happened to a submitted bundle.
+type BundleStatus struct {
+	BundleHash        string        `json:"bundleHash"`
+	Searcher          string        `json:"searcher"`
+	TargetBlock       string        `json:"targetBlock"`
+	ReceivedAt        time.Time     `json:"receivedAt"`
+	IsSimulated       bool          `json:"isSimulated"`
+	SimulatedAt       *time.Time    `json:"simulatedAt,omitempty"`
+	Simulation        gin.H         `json:"simulation,omitempty"`
+	Builds            []BuildStatus `json:"builds"`
+	SelectedByBuilder bool          `json:"selectedByBuilder"`
+	BlockHash         string        `json:"blockHash,omitempty"`
+	Dropped           bool          `json:"dropped"`
+	DropReason        string        `json:"dropReason,omitempty"`
+}
+
+// BuildStatus is one builder's answer to the bundle.
+type BuildStatus struct {
+	Builder   string    `json:"builder"`
+	At        time.Time `json:"timestamp"`
+	LatencyMs int64     `json:"latencyMs"`
+	BlockHash string    `json:"blockHash,omitempty"`
+	Included  bool      `json:"included"`
+	Reason    string    `json:"reason,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	// RefundWei is what the builder pays the user of a backrun, in the
+	// transaction RefundTxHash appended to its block.
+	RefundWei       string `json:"refundWei,omitempty"`
+	RefundRecipient string `json:"refundRecipient,omitempty"`
+	RefundTxHash    string `json:"refundTxHash,omitempty"`
+}
+
+// BundleStatsParams are the parameters of flashbots_getBundleStatsV2.
+type BundleStatsParams struct {
+	BundleHash  string `json:"bundleHash"`
+	BlockNumber string `json:"blockNumber"`
+}
+
+// status snapshots the record's lifecycle.
+func (r *bundleRecord) status() BundleStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := BundleStatus{
+		BundleHash:  r.Hash,
+		Searcher:    r.Searcher.Hex(),
+		TargetBlock: r.TargetBlock,
+		ReceivedAt:  r.ReceivedAt,
+		Builds:      []BuildStatus{},
+	}
+
+	if r.result != nil {
+		simulatedAt := r.simulatedAt
+		st.IsSimulated = true
+		st.SimulatedAt = &simulatedAt
+		st.Simulation = simulationJSON(r.result, r.MatchedTxHash)
+	}
+
+	switch {
+	case r.cancelReason != "":
+		st.Dropped = true
+		st.DropReason = r.cancelReason
+	case r.err != nil:
+		st.Dropped = true
+		st.DropReason = "simulation unavailable: " + r.err.Error()
+	case r.result != nil && !r.result.Success:
+		st.Dropped = true
+		st.DropReason = "simulation failed: " + r.result.Reason
+	}
+
+	for _, build := range r.builds {
+		bs := BuildStatus{Builder: build.Builder, At: build.At, LatencyMs: build.Latency.Milliseconds()}
+		if build.Err != nil {
+			bs.Error = build.Err.Error()
+		} else {
+			bs.BlockHash = build.Result.BlockHash
+			bs.Included = build.Result.Included
+			bs.Reason = build.Result.InclusionReason
+			bs.RefundWei = build.Result.RefundWei
+			bs.RefundRecipient = build.Result.RefundRecipient
+			bs.RefundTxHash = build.Result.RefundTxHash
+		}
+		st.Builds = append(st.Builds, bs)
+
+		if bs.Included && !st.SelectedByBuilder {
+			st.SelectedByBuilder = true
+			st.BlockHash = bs.BlockHash
+		}
+	}
+
+	if len(r.builds) > 0 && !st.SelectedByBuilder && !st.Dropped {
+		st.Dropped = true
+		st.DropReason = "not selected by any builder"
+	}
+
+	return st
+}
+
+// handleBundleStats serves flashbots_getBundleStatsV2. Searchers can only
+// look up their own bundles.
+func (s *Server) handleBundleStats(c *gin.Context, req BundleRPCRequest) (interface{}, *RPCError) {
+	var params []BundleStatsParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+		return nil, invalidParams("expected [{bundleHash, blockNumber}]")
+	}
+
+	record := s.bundles.find(searcherFrom(c), params[0].BundleHash, params[0].BlockNumber)
+	if record == nil {
+		return nil, newRPCError(errCodeNotFound, "bundle not found")
+	}
+
+	return record.status(), nil
+}
+
+// handleBundleLookup serves GET /relay/v1/bundle/:hash.
+func (s *Server) handleBundleLookup(c *gin.Context) {
+	record := s.bundles.find(searcherFrom(c), c.Param("hash"), c.Query("blockNumber"))
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bundle not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record.status())
+}
+
+func simulationJSON(result *pb.BundleResponse, matchedTxHash string) gin.H {
+	return gin.H{
+		"success":           result.Success,
+		"reason":            result.Reason,
+		"profitEth":         result.ProfitEth,
+		"latencyMs":         result.LatencyMs,
+		"coinbaseDiff":      result.CoinbaseDiff,
+		"gasFees":           result.GasFees,
+		"ethSentToCoinbase": result.EthSentToCoinbase,
+		"gasUsed":           result.GasUsed,
+		"stateBlockNumber":  result.StateBlock,
+		"results":           txResultsJSON(result.Results, matchedTxHash),
+	}
+}
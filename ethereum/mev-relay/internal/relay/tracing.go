@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("mev-relay/internal/relay")
+
+// traceRequests runs every request in a server span, continuing the trace
+// of the caller's traceparent header if there is one.
+func traceRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(c.Request.Method), semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// traceRPC runs a JSON-RPC method in its own span, so that the methods of a
+// batch are told apart. The handler sees the span in c.Request's context.
+func traceRPC(c *gin.Context, method string, handler func() (interface{}, *RPCError)) (interface{}, *RPCError) {
+	ctx, span := tracer.Start(c.Request.Context(), method,
+		trace.WithAttributes(semconv.RPCSystemKey.String("jsonrpc"), semconv.RPCMethod(method)),
+	)
+	defer span.End()
+
+	request := c.Request
+	c.Request = request.WithContext(ctx)
+	defer func() { c.Request = request }()
+
+	result, rpcErr := handler()
+	if rpcErr != nil {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", rpcErr.Code))
+		span.SetStatus(codes.Error, rpcErr.Message)
+	}
+	return result, rpcErr
+}
+
+// startBundleSpan starts the span following a bundle from its submission
+// until its builds are forwarded. The bundle outlives the request that
+// submitted it, so the returned context is detached from the request's
+// cancellation; the span is ended by the worker processing the bundle, or
+// when the bundle is dropped from the queue.
+func startBundleSpan(ctx context.Context, hash string, searcher common.Address, targetBlock string, txCount int) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "bundle", trace.WithAttributes(
+		attribute.String("bundle.hash", hash),
+		attribute.String("bundle.searcher", searcher.Hex()),
+		attribute.String("bundle.target_block", targetBlock),
+		attribute.Int("bundle.tx_count", txCount),
+	))
+	return context.WithoutCancel(ctx), span
+}
+
+// endSpan records err, if any, as the span's status and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
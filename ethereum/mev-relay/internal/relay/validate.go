@@ -0,0 +1,109 @@
+package relay
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// decodedTx is a bundle transaction together with its recovered sender.
+type decodedTx struct {
+	Raw  string
+	Tx   *types.Transaction
+	From common.Address
+}
+
+// validateBundle checks the eth_sendBundle parameters against the chain head
+// and the relay's chain ID, and decodes every raw transaction. It returns the
+// decoded transactions and the target block number.
+func (s *Server) validateBundle(ctx context.Context, params BundleRPCParams) ([]decodedTx, uint64, *RPCError) {
+	if len(params.Txs) == 0 {
+		return nil, 0, invalidParams("bundle has no transactions")
+	}
+
+	target, rpcErr := s.validateTarget(ctx, params.BlockNumber)
+	if rpcErr != nil {
+		return nil, 0, rpcErr
+	}
+
+	if params.MinTimestamp < 0 || params.MaxTimestamp < 0 {
+		return nil, 0, invalidParams("timestamps must not be negative")
+	}
+	if params.MaxTimestamp != 0 && params.MinTimestamp > params.MaxTimestamp {
+		return nil, 0, invalidParams("minTimestamp %d is after maxTimestamp %d", params.MinTimestamp, params.MaxTimestamp)
+	}
+	if params.MaxTimestamp != 0 && time.Now().Unix() > params.MaxTimestamp {
+		return nil, 0, invalidParams("bundle expired at %d", params.MaxTimestamp)
+	}
+
+	for _, hash := range params.RevertingTxHashes {
+		if b, err := hexutil.Decode(hash); err != nil || len(b) != common.HashLength {
+			return nil, 0, invalidParams("invalid reverting tx hash %q", hash)
+		}
+	}
+
+	txs, rpcErr := decodeTxs(params.Txs, big.NewInt(s.cfg.ChainID))
+	if rpcErr != nil {
+		return nil, 0, rpcErr
+	}
+	return txs, target, nil
+}
+
+// validateTarget parses a target block number and checks that it has not
+// been mined yet.
+func (s *Server) validateTarget(ctx context.Context, blockNumber string) (uint64, *RPCError) {
+	target, err := hexutil.DecodeUint64(blockNumber)
+	if err != nil {
+		return 0, invalidParams("invalid blockNumber %q: %v", blockNumber, err)
+	}
+
+	head, err := s.eth.BlockNumber(ctx)
+	if err != nil {
+		return 0, newRPCError(errCodeInternal, "chain head unavailable")
+	}
+	if target <= head {
+		return 0, invalidParams("target block %d is not in the future (head %d)", target, head)
+	}
+	return target, nil
+}
+
+// decodeTxs RLP-decodes raw transactions of any supported type (legacy,
+// EIP-2930, EIP-1559, EIP-4844 in network form) and recovers their senders.
+func decodeTxs(raws []string, chainID *big.Int) ([]decodedTx, *RPCError) {
+	signer := types.LatestSignerForChainID(chainID)
+
+	txs := make([]decodedTx, 0, len(raws))
+	for i, raw := range raws {
+		data, err := hexutil.Decode(raw)
+		if err != nil {
+			return nil, invalidParams("tx %d: invalid hex: %v", i, err)
+		}
+
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return nil, invalidParams("tx %d: invalid transaction: %v", i, err)
+		}
+
+		if !tx.Protected() {
+			return nil, invalidParams("tx %d: not replay protected", i)
+		}
+		if tx.ChainId().Cmp(chainID) != 0 {
+			return nil, invalidParams("tx %d: chain ID %s does not match %s", i, tx.ChainId(), chainID)
+		}
+		if tx.Type() == types.BlobTxType && tx.BlobTxSidecar() == nil {
+			return nil, invalidParams("tx %d: blob transaction without sidecar", i)
+		}
+
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, invalidParams("tx %d: invalid signature: %v", i, err)
+		}
+
+		txs = append(txs, decodedTx{Raw: raw, Tx: tx, From: from})
+	}
+	return txs, nil
+}
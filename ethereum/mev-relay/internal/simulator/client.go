@@ -2,13 +2,22 @@ package simulator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("mev-relay/internal/simulator")
+
 type RPCRequest struct {
 	Jsonrpc string        `json:"jsonrpc"`
 	Method  string        `json:"method"`
@@ -24,11 +33,31 @@ type RPCResponse struct {
 }
 
 type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
-func callRPC(rpcURL string, method string, params []interface{}) (*RPCResponse, error) {
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// callRPC sends one JSON-RPC request to the node in a span named after the
+// method. Cleanup calls that must outlive the caller are given a context
+// without cancellation by their callers.
+func callRPC(ctx context.Context, rpcURL string, method string, params []interface{}) (resp *RPCResponse, err error) {
+	ctx, span := tracer.Start(ctx, "anvil "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", method)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	reqBody, _ := json.Marshal(RPCRequest{
 		Jsonrpc: "2.0",
 		Method:  method,
@@ -36,14 +65,21 @@ func callRPC(rpcURL string, method string, params []interface{}) (*RPCResponse,
 		ID:      1,
 	})
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("RPC call failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	httpClient := &http.Client{Timeout: 5 * time.Second}
-	resp, err := httpClient.Post(rpcURL, "application/json", bytes.NewBuffer(reqBody))
+	httpResp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("RPC call failed: %w", err)
 	}
-	defer resp.Body.Close()
+	defer httpResp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	body, _ := io.ReadAll(httpResp.Body)
 
 	var rpcResp RPCResponse
 	if err := json.Unmarshal(body, &rpcResp); err != nil {
@@ -51,8 +87,26 @@ func callRPC(rpcURL string, method string, params []interface{}) (*RPCResponse,
 	}
 
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("EVM error: %s", rpcResp.Error.Message)
+		return nil, fmt.Errorf("EVM error: %w", rpcResp.Error)
 	}
 
 	return &rpcResp, nil
 }
+
+// call invokes an RPC method on the node and decodes its result into out.
+// A nil out discards the result.
+func call(ctx context.Context, rpcURL string, out interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	resp, err := callRPC(ctx, rpcURL, method, params)
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, out)
+}
@@ -1,37 +1,464 @@
 package simulator
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"math/rand"
-	"time"
+	"log"
+	"math/big"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"mev-relay/internal/config"
+	"mev-relay/internal/pb"
 )
 
-// RunSimulation executes bundle transactions in a forked Anvil or Geth instance.
-// In this mock version, we simulate success and profitability.
-func RunSimulation(cfg *config.Config, txs []string) (bool, float64, error) {
-	// Example: call eth_call or debug_traceCall for first tx to ensure connectivity
-	_, err := callRPC(cfg.AnvilRPC, "eth_chainId", []interface{}{})
-	if err != nil {
-		return false, 0, fmt.Errorf("node unavailable: %w", err)
+// TxResult is the outcome of a single bundle transaction on the simulation node.
+type TxResult struct {
+	TxHash            string
+	GasUsed           uint64
+	Success           bool
+	RevertReason      string
+	Logs              []Log
+	CoinbaseDiff      *big.Int
+	GasFees           *big.Int
+	EthSentToCoinbase *big.Int
+	Error             string
+}
+
+// Log is an event emitted by a bundle transaction.
+type Log struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// SimulationResult is the outcome of executing a whole bundle in order.
+// CoinbaseDiff is what the proposer earns from the bundle; it splits into the
+// priority fees paid by the transactions (GasFees) and any direct transfers to
+// the coinbase (EthSentToCoinbase).
+type SimulationResult struct {
+	Success           bool
+	Reason            string
+	StateBlock        uint64
+	GasUsed           uint64
+	CoinbaseDiff      *big.Int
+	GasFees           *big.Int
+	EthSentToCoinbase *big.Int
+	ProfitEth         float64
+	Txs               []TxResult
+}
+
+type rpcReceipt struct {
+	TxHash            common.Hash    `json:"transactionHash"`
+	BlockNumber       hexutil.Uint64 `json:"blockNumber"`
+	Status            hexutil.Uint64 `json:"status"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	Logs              []Log          `json:"logs"`
+}
+
+type rpcBlock struct {
+	Number        hexutil.Uint64 `json:"number"`
+	Timestamp     hexutil.Uint64 `json:"timestamp"`
+	Miner         common.Address `json:"miner"`
+	BaseFeePerGas *hexutil.Big   `json:"baseFeePerGas"`
+	Transactions  []common.Hash  `json:"transactions"`
+}
+
+// callFrame is a call made by a transaction, as reported by the node's
+// callTracer. A frame with an error was reverted together with its calls.
+type callFrame struct {
+	Type   string         `json:"type"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Output hexutil.Bytes  `json:"output"`
+	Error  string         `json:"error"`
+	Calls  []callFrame    `json:"calls"`
+}
+
+var (
+	// The simulation node holds a single chain state, so bundles are
+	// executed one at a time between a snapshot and a revert.
+	simMu sync.Mutex
+	// forkHead is the chain block the simulation node is forked at, and
+	// forkDirty is set when a simulation could not be reverted. Both are
+	// guarded by simMu.
+	forkHead  uint64
+	forkDirty = true
+)
+
+// RunSimulation executes the bundle's raw transactions in order on top of the
+// latest state of the chain, which is the parent state of the target block.
+// The bundle runs on the simulation node (ANVIL_RPC), a dedicated Anvil
+// instance forked from the chain node (GETH_RPC) and started without mining:
+// the chain node is only read, so other readers never see simulated blocks
+// and bundle transactions never reach its mempool. The fork is reset to the
+// chain head whenever the head moves.
+//
+// The transactions are sent with mining paused and mined together into a
+// single block, as a builder would include them, so they all see the same
+// block number, timestamp and base fee. The node must keep them in the order
+// they were sent (anvil --order fifo). Per-transaction results are read from
+// the block's receipts and call traces; the fork is reverted to its snapshot
+// and its pending transactions dropped afterwards. An error is returned only
+// when a node fails or the request cannot be simulated, a bundle that does
+// not execute cleanly is reported through the result.
+//
+// Only the latest state is available, so a state block other than "latest"
+// must equal the current head. The block is mined at the target block's
+// expected timestamp, the head's plus ANVIL_BLOCK_TIME per block, unless the
+// request overrides it with a non-zero timestamp. The bundle fails when that
+// timestamp is outside the request's timestamp window, or when a transaction
+// reverts, unless its hash is listed in the request's reverting transaction
+// hashes.
+func RunSimulation(ctx context.Context, cfg *config.Config, req *pb.BundleRequest) (*SimulationResult, error) {
+	txs, targetBlock := req.Txs, req.TargetBlock
+	if len(txs) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+
+	// Simulations queue up on simMu; one whose caller has given up by now
+	// must not hold up the rest.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	simMu.Lock()
+	defer simMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	node := cfg.AnvilRPC
+
+	var latest *rpcBlock
+	if err := call(ctx, cfg.GethRPC, &latest, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, fmt.Errorf("node unavailable: %w", err)
+	}
+	if latest == nil {
+		return nil, errors.New("node unavailable: no head block")
+	}
+	head := latest.Number
+
+	timestamp := req.Timestamp
+	if targetBlock != "" {
+		target, err := hexutil.DecodeUint64(targetBlock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target block %q: %w", targetBlock, err)
+		}
+		if target <= uint64(head) {
+			return nil, fmt.Errorf("target block %d already mined (head %d)", target, head)
+		}
+		if timestamp == 0 {
+			timestamp = int64(latest.Timestamp) + int64(target-uint64(head))*int64(cfg.AnvilBlockTime)
+		}
+	}
+
+	if req.StateBlock != "" && req.StateBlock != "latest" {
+		stateBlock, err := hexutil.DecodeUint64(req.StateBlock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state block %q: %w", req.StateBlock, err)
+		}
+		if stateBlock != uint64(head) {
+			return nil, fmt.Errorf("state block %d not available, node is at %d", stateBlock, head)
+		}
+	}
+
+	if err := syncFork(ctx, cfg, uint64(head)); err != nil {
+		return nil, fmt.Errorf("simulation node unavailable: %w", err)
+	}
+
+	var snapshot string
+	if err := call(ctx, node, &snapshot, "evm_snapshot"); err != nil {
+		return nil, fmt.Errorf("snapshot failed: %w", err)
+	}
+	// Cleanup must run even when the caller has given up on the simulation.
+	defer restoreFork(context.WithoutCancel(ctx), node, snapshot)
+
+	allowedToRevert := make(map[string]bool, len(req.RevertingTxHashes))
+	for _, hash := range req.RevertingTxHashes {
+		allowedToRevert[common.HexToHash(hash).Hex()] = true
+	}
+
+	result := &SimulationResult{
+		Success:           true,
+		StateBlock:        uint64(head),
+		CoinbaseDiff:      new(big.Int),
+		GasFees:           new(big.Int),
+		EthSentToCoinbase: new(big.Int),
+	}
+
+	// A transaction the node refuses ends the bundle; the ones before it
+	// are still mined so that their results can be reported.
+	hashes := make([]common.Hash, 0, len(txs))
+	var rejected string
+	for _, raw := range txs {
+		var hash common.Hash
+		if err := call(ctx, node, &hash, "eth_sendRawTransaction", raw); err != nil {
+			rejected = err.Error()
+			break
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if len(hashes) > 0 {
+		block, err := mineBundle(ctx, node, hashes, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if reason := outsideWindow(int64(block.Timestamp), req.MinTimestamp, req.MaxTimestamp); reason != "" {
+			result.Success = false
+			result.Reason = reason
+		}
+
+		for i, hash := range hashes {
+			txResult, err := executedTx(ctx, node, hash, block)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Txs = append(result.Txs, txResult)
+			result.GasUsed += txResult.GasUsed
+			result.GasFees.Add(result.GasFees, txResult.GasFees)
+
+			if txResult.Error != "" && result.Success {
+				result.Success = false
+				result.Reason = fmt.Sprintf("tx %d (%s) not included: %s", i, txResult.TxHash, txResult.Error)
+			}
+			if txResult.Error == "" && !txResult.Success && !allowedToRevert[txResult.TxHash] && result.Success {
+				result.Success = false
+				result.Reason = fmt.Sprintf("tx %d (%s) reverted", i, txResult.TxHash)
+			}
+		}
+
+		diff, err := coinbaseDiff(ctx, node, block)
+		if err != nil {
+			return nil, err
+		}
+		result.CoinbaseDiff = diff
+		result.EthSentToCoinbase = new(big.Int).Sub(diff, result.GasFees)
+	}
+
+	if rejected != "" {
+		result.Txs = append(result.Txs, TxResult{
+			CoinbaseDiff:      new(big.Int),
+			GasFees:           new(big.Int),
+			EthSentToCoinbase: new(big.Int),
+			Error:             rejected,
+		})
+		if result.Success {
+			result.Success = false
+			result.Reason = fmt.Sprintf("tx %d rejected: %s", len(hashes), rejected)
+		}
+	}
+
+	result.ProfitEth = weiToEth(result.CoinbaseDiff)
+	return result, nil
+}
+
+// outsideWindow returns why a block with the given timestamp cannot include
+// a bundle valid between minTimestamp and maxTimestamp, or "" if it can. A
+// zero bound is open.
+func outsideWindow(timestamp, minTimestamp, maxTimestamp int64) string {
+	if minTimestamp > 0 && timestamp < minTimestamp {
+		return fmt.Sprintf("block timestamp %d is before minTimestamp %d", timestamp, minTimestamp)
+	}
+	if maxTimestamp > 0 && timestamp > maxTimestamp {
+		return fmt.Sprintf("block timestamp %d is after maxTimestamp %d", timestamp, maxTimestamp)
+	}
+	return ""
+}
+
+// mineBundle mines the pending bundle transactions into one block, at the
+// given timestamp when it is non-zero, and returns the block. The block may
+// leave out transactions, such as those that no longer fit its gas limit,
+// but must hold no others and keep the bundle order.
+func mineBundle(ctx context.Context, node string, hashes []common.Hash, timestamp int64) (*rpcBlock, error) {
+	var mineParams []interface{}
+	if timestamp > 0 {
+		mineParams = append(mineParams, timestamp)
+	}
+	if err := call(ctx, node, nil, "evm_mine", mineParams...); err != nil {
+		return nil, fmt.Errorf("mining failed: %w", err)
 	}
 
-	// Simulate computation time
-	time.Sleep(time.Duration(500+rand.Intn(400)) * time.Millisecond)
+	var block *rpcBlock
+	if err := call(ctx, node, &block, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, fmt.Errorf("block lookup failed: %w", err)
+	}
+	if block == nil {
+		return nil, errors.New("mined block not found")
+	}
 
-	// Randomized profit to mimic variable outcomes
-	profit := 0.001 + rand.Float64()*0.005
-	success := rand.Intn(100) > 10 // 90% chance of success
+	position := make(map[common.Hash]int, len(hashes))
+	for i, hash := range hashes {
+		position[hash] = i
+	}
+	last := -1
+	for _, hash := range block.Transactions {
+		i, ok := position[hash]
+		if !ok {
+			return nil, fmt.Errorf("mined block holds foreign transaction %s", hash.Hex())
+		}
+		if i < last {
+			return nil, errors.New("simulation node reordered the bundle, it must run with --order fifo")
+		}
+		last = i
+	}
+	return block, nil
+}
+
+// executedTx reads back the outcome of a bundle transaction mined into block:
+// its receipt, and its call trace for the revert reason and the ether it
+// sent to the coinbase.
+func executedTx(ctx context.Context, node string, hash common.Hash, block *rpcBlock) (TxResult, error) {
+	res := TxResult{
+		TxHash:            hash.Hex(),
+		CoinbaseDiff:      new(big.Int),
+		GasFees:           new(big.Int),
+		EthSentToCoinbase: new(big.Int),
+	}
+
+	var receipt *rpcReceipt
+	if err := call(ctx, node, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return res, fmt.Errorf("receipt lookup failed: %w", err)
+	}
+	if receipt == nil || receipt.BlockNumber != block.Number {
+		res.Error = "transaction was not mined in the bundle's block"
+		return res, nil
+	}
+
+	res.GasUsed = uint64(receipt.GasUsed)
+	res.Success = receipt.Status == 1
+	res.Logs = receipt.Logs
+
+	var trace callFrame
+	if err := call(ctx, node, &trace, "debug_traceTransaction", hash, map[string]interface{}{"tracer": "callTracer"}); err != nil {
+		return res, fmt.Errorf("trace failed: %w", err)
+	}
+	if !res.Success {
+		res.RevertReason = revertReason(&trace)
+	}
+
+	res.GasFees = priorityFees(receipt, block)
+	res.EthSentToCoinbase = sentTo(&trace, block.Miner)
+	res.CoinbaseDiff = new(big.Int).Add(res.GasFees, res.EthSentToCoinbase)
+	return res, nil
+}
+
+// revertReason decodes the revert data of a reverted transaction's top call.
+// Error(string) and Panic(uint256) are decoded; custom errors are returned as
+// raw hex.
+func revertReason(trace *callFrame) string {
+	if len(trace.Output) == 0 {
+		if trace.Error != "" {
+			return trace.Error
+		}
+		return "execution reverted"
+	}
+	if reason, err := abi.UnpackRevert(trace.Output); err == nil {
+		return reason
+	}
+	return trace.Output.String()
+}
+
+// sentTo sums the ether the calls of frame transfer to address, skipping the
+// calls that were reverted.
+func sentTo(frame *callFrame, address common.Address) *big.Int {
+	total := new(big.Int)
+	if frame.Error != "" {
+		return total
+	}
 
-	// For demonstration, serialize results as trace output
-	traceData, _ := json.Marshal(map[string]interface{}{
-		"tx_count": len(txs),
-		"profit":   profit,
-		"success":  success,
-	})
-	_ = traceData
+	switch frame.Type {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		if frame.To == address && frame.Value != nil {
+			total.Add(total, frame.Value.ToInt())
+		}
+	}
+	for i := range frame.Calls {
+		total.Add(total, sentTo(&frame.Calls[i], address))
+	}
+	return total
+}
+
+// priorityFees returns the part of the transaction fee that went to the
+// coinbase, i.e. gas used times the tip above the block's base fee.
+func priorityFees(receipt *rpcReceipt, block *rpcBlock) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return new(big.Int)
+	}
+
+	tip := new(big.Int).Set(receipt.EffectiveGasPrice.ToInt())
+	if block.BaseFeePerGas != nil {
+		tip.Sub(tip, block.BaseFeePerGas.ToInt())
+	}
+	return tip.Mul(tip, new(big.Int).SetUint64(uint64(receipt.GasUsed)))
+}
+
+// coinbaseDiff returns the balance change of the block's fee recipient
+// between the parent block and the given block.
+func coinbaseDiff(ctx context.Context, node string, block *rpcBlock) (*big.Int, error) {
+	number := uint64(block.Number)
+
+	var before, after hexutil.Big
+	if err := call(ctx, node, &before, "eth_getBalance", block.Miner, hexutil.Uint64(number-1)); err != nil {
+		return nil, fmt.Errorf("balance lookup failed: %w", err)
+	}
+	if err := call(ctx, node, &after, "eth_getBalance", block.Miner, hexutil.Uint64(number)); err != nil {
+		return nil, fmt.Errorf("balance lookup failed: %w", err)
+	}
+
+	return new(big.Int).Sub(after.ToInt(), before.ToInt()), nil
+}
+
+// syncFork makes sure the simulation node holds the chain state at head:
+// the fork is reset when the chain has moved on or a previous simulation
+// left it dirty. Mining is switched off on every sync so that only the
+// simulator's evm_mine calls produce blocks, even if the node was started
+// with mining enabled. Callers must hold simMu.
+func syncFork(ctx context.Context, cfg *config.Config, head uint64) error {
+	if forkDirty || forkHead != head {
+		forking := map[string]interface{}{
+			"forking": map[string]interface{}{"jsonRpcUrl": cfg.GethRPC, "blockNumber": head},
+		}
+		if err := call(ctx, cfg.AnvilRPC, nil, "anvil_reset", forking); err != nil {
+			forkDirty = true
+			return fmt.Errorf("resetting fork to block %d: %w", head, err)
+		}
+		forkHead, forkDirty = head, false
+	}
+
+	if err := call(ctx, cfg.AnvilRPC, nil, "evm_setAutomine", false); err != nil {
+		forkDirty = true
+		return fmt.Errorf("pausing mining: %w", err)
+	}
+	if err := call(ctx, cfg.AnvilRPC, nil, "evm_setIntervalMining", 0); err != nil {
+		forkDirty = true
+		return fmt.Errorf("pausing mining: %w", err)
+	}
+	return nil
+}
+
+// restoreFork reverts the simulation node to the snapshot taken before the
+// simulation and drops every transaction still pending on it, such as one
+// that was accepted but never mined. A fork that cannot be restored is reset
+// before the next simulation. Callers must hold simMu.
+func restoreFork(ctx context.Context, node, snapshot string) {
+	var reverted bool
+	if err := call(ctx, node, &reverted, "evm_revert", snapshot); err != nil || !reverted {
+		log.Printf("[Simulator] Failed to revert snapshot %s: %v", snapshot, err)
+		forkDirty = true
+	}
+	if err := call(ctx, node, nil, "anvil_dropAllTransactions"); err != nil {
+		log.Println("[Simulator] Failed to drop pending transactions:", err)
+		forkDirty = true
+	}
+}
 
-	return success, profit, nil
+func weiToEth(wei *big.Int) float64 {
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return eth
 }
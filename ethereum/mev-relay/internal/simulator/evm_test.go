@@ -0,0 +1,340 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"mev-relay/internal/config"
+	"mev-relay/internal/pb"
+)
+
+const (
+	fakeHead      = 100
+	fakeHeadTime  = 1_700_000_000
+	fakeBaseFee   = 7
+	fakeTip       = 2
+	fakeGasPerTx  = 21_000
+	fakeCoinbase0 = 1_000_000
+)
+
+var fakeCoinbase = common.HexToAddress("0xc0ffee0000000000000000000000000000000001")
+
+// fakeNode serves both the chain node and the simulation node. Sent
+// transactions stay pending until evm_mine puts them all in one block.
+type fakeNode struct {
+	mu       sync.Mutex
+	reorder  bool                        // mine the pending transactions in reverse
+	refuse   map[string]bool             // raw transactions eth_sendRawTransaction rejects
+	traces   map[common.Hash]interface{} // callTracer output, a plain call by default
+	pending  []common.Hash
+	block    *rpcBlock
+	mineArgs [][]interface{}
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var result interface{}
+	var rpcErr *RPCError
+	if r.URL.Path == "/geth" {
+		result, rpcErr = n.handleChain(req.Method)
+	} else {
+		result, rpcErr = n.handle(req.Method, req.Params)
+	}
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result}
+	if rpcErr != nil {
+		resp = map[string]interface{}{"jsonrpc": "2.0", "id": 1, "error": rpcErr}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleChain serves the chain node, which is only asked for its head.
+func (n *fakeNode) handleChain(method string) (interface{}, *RPCError) {
+	if method != "eth_getBlockByNumber" {
+		return nil, &RPCError{Code: -32601, Message: "method not found: " + method}
+	}
+	return &rpcBlock{Number: fakeHead, Timestamp: fakeHeadTime, Miner: fakeCoinbase}, nil
+}
+
+func (n *fakeNode) handle(method string, params []json.RawMessage) (interface{}, *RPCError) {
+	switch method {
+	case "anvil_reset", "evm_setAutomine", "evm_setIntervalMining", "anvil_dropAllTransactions":
+		return nil, nil
+	case "evm_snapshot":
+		return "0x1", nil
+	case "evm_revert":
+		n.block, n.pending = nil, nil
+		return true, nil
+	case "eth_sendRawTransaction":
+		var raw string
+		_ = json.Unmarshal(params[0], &raw)
+		if n.refuse[raw] {
+			return nil, &RPCError{Code: -32000, Message: "nonce too low"}
+		}
+		hash := crypto.Keccak256Hash([]byte(raw))
+		n.pending = append(n.pending, hash)
+		return hash, nil
+	case "evm_mine":
+		var args []interface{}
+		for _, p := range params {
+			var arg interface{}
+			_ = json.Unmarshal(p, &arg)
+			args = append(args, arg)
+		}
+		n.mineArgs = append(n.mineArgs, args)
+
+		timestamp := hexutil.Uint64(fakeHeadTime + 1)
+		if len(args) > 0 {
+			timestamp = hexutil.Uint64(args[0].(float64))
+		}
+		txs := n.pending
+		if n.reorder {
+			txs = make([]common.Hash, len(n.pending))
+			for i, hash := range n.pending {
+				txs[len(txs)-1-i] = hash
+			}
+		}
+		n.block = &rpcBlock{
+			Number:        fakeHead + 1,
+			Timestamp:     timestamp,
+			Miner:         fakeCoinbase,
+			BaseFeePerGas: (*hexutil.Big)(big.NewInt(fakeBaseFee)),
+			Transactions:  txs,
+		}
+		n.pending = nil
+		return "0x0", nil
+	case "eth_getBlockByNumber":
+		return n.block, nil
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		_ = json.Unmarshal(params[0], &hash)
+		if n.block == nil || !containsHash(n.block.Transactions, hash) {
+			return nil, nil
+		}
+		return map[string]interface{}{
+			"transactionHash":   hash,
+			"blockNumber":       n.block.Number,
+			"status":            hexutil.Uint64(1),
+			"gasUsed":           hexutil.Uint64(fakeGasPerTx),
+			"effectiveGasPrice": (*hexutil.Big)(big.NewInt(fakeBaseFee + fakeTip)),
+			"logs":              []Log{},
+		}, nil
+	case "debug_traceTransaction":
+		var hash common.Hash
+		_ = json.Unmarshal(params[0], &hash)
+		if trace, ok := n.traces[hash]; ok {
+			return trace, nil
+		}
+		return map[string]interface{}{"type": "CALL", "to": common.Address{0x1}, "value": "0x0"}, nil
+	case "eth_getBalance":
+		var number hexutil.Uint64
+		_ = json.Unmarshal(params[1], &number)
+		balance := big.NewInt(fakeCoinbase0)
+		if uint64(number) > fakeHead && n.block != nil {
+			// Priority fees of the block plus what the traces send.
+			balance.Add(balance, big.NewInt(int64(len(n.block.Transactions)*fakeGasPerTx*fakeTip)))
+			for _, hash := range n.block.Transactions {
+				if trace, ok := n.traces[hash]; ok {
+					data, _ := json.Marshal(trace)
+					var frame callFrame
+					_ = json.Unmarshal(data, &frame)
+					balance.Add(balance, sentTo(&frame, fakeCoinbase))
+				}
+			}
+		}
+		return (*hexutil.Big)(balance), nil
+	}
+	return nil, &RPCError{Code: -32601, Message: "method not found: " + method}
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func newFakeNode(t *testing.T, node *fakeNode) *config.Config {
+	t.Helper()
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	return &config.Config{GethRPC: srv.URL + "/geth", AnvilRPC: srv.URL + "/anvil", AnvilBlockTime: 12}
+}
+
+func TestRunSimulationMinesBundleInOneBlock(t *testing.T) {
+	txs := []string{"0x01", "0x02", "0x03"}
+	payer := crypto.Keccak256Hash([]byte(txs[1]))
+	refunded := crypto.Keccak256Hash([]byte(txs[2]))
+
+	node := &fakeNode{traces: map[common.Hash]interface{}{
+		// Pays the coinbase from an inner call.
+		payer: map[string]interface{}{"type": "CALL", "to": common.Address{0x1}, "value": "0x0", "calls": []interface{}{
+			map[string]interface{}{"type": "CALL", "to": fakeCoinbase, "value": "0x64"},
+		}},
+		// Tries to pay the coinbase from a call that reverts.
+		refunded: map[string]interface{}{"type": "CALL", "to": common.Address{0x1}, "value": "0x0", "calls": []interface{}{
+			map[string]interface{}{"type": "CALL", "to": common.Address{0x2}, "value": "0x0", "error": "execution reverted", "calls": []interface{}{
+				map[string]interface{}{"type": "CALL", "to": fakeCoinbase, "value": "0x64"},
+			}},
+		}},
+	}}
+	cfg := newFakeNode(t, node)
+
+	result, err := RunSimulation(context.Background(), cfg, &pb.BundleRequest{
+		Txs:         txs,
+		TargetBlock: hexutil.EncodeUint64(fakeHead + 1),
+		Timestamp:   fakeHeadTime + 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(node.mineArgs) != 1 {
+		t.Fatalf("mined %d blocks, want 1", len(node.mineArgs))
+	}
+	if args := node.mineArgs[0]; len(args) != 1 || args[0] != float64(fakeHeadTime+30) {
+		t.Errorf("evm_mine params = %v, want the request timestamp", args)
+	}
+	if !result.Success {
+		t.Fatalf("simulation failed: %s", result.Reason)
+	}
+
+	fees := int64(fakeGasPerTx * fakeTip)
+	wantDiffs := []int64{fees, fees + 100, fees}
+	if len(result.Txs) != len(wantDiffs) {
+		t.Fatalf("got %d results, want %d", len(result.Txs), len(wantDiffs))
+	}
+	for i, want := range wantDiffs {
+		if got := result.Txs[i].CoinbaseDiff.Int64(); got != want {
+			t.Errorf("tx %d coinbase diff = %d, want %d", i, got, want)
+		}
+	}
+	if got, want := result.CoinbaseDiff.Int64(), 3*fees+100; got != want {
+		t.Errorf("coinbase diff = %d, want %d", got, want)
+	}
+	if got := result.EthSentToCoinbase.Int64(); got != 100 {
+		t.Errorf("eth sent to coinbase = %d, want 100", got)
+	}
+	if result.GasUsed != 3*fakeGasPerTx {
+		t.Errorf("gas used = %d, want %d", result.GasUsed, 3*fakeGasPerTx)
+	}
+}
+
+func TestRunSimulationRejectsReorderedBlock(t *testing.T) {
+	cfg := newFakeNode(t, &fakeNode{reorder: true})
+
+	_, err := RunSimulation(context.Background(), cfg, &pb.BundleRequest{Txs: []string{"0x01", "0x02"}})
+	if err == nil || !strings.Contains(err.Error(), "--order fifo") {
+		t.Fatalf("err = %v, want a reordering error", err)
+	}
+}
+
+func TestRunSimulationReportsRefusedTransaction(t *testing.T) {
+	node := &fakeNode{refuse: map[string]bool{"0x02": true}}
+	cfg := newFakeNode(t, node)
+
+	result, err := RunSimulation(context.Background(), cfg, &pb.BundleRequest{Txs: []string{"0x01", "0x02", "0x03"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !strings.HasPrefix(result.Reason, "tx 1 rejected") {
+		t.Fatalf("result = %v %q, want tx 1 rejected", result.Success, result.Reason)
+	}
+	if len(result.Txs) != 2 || !result.Txs[0].Success || result.Txs[1].Error == "" {
+		t.Errorf("results = %+v, want the mined tx 0 then the refused tx 1", result.Txs)
+	}
+	if len(node.mineArgs) != 1 {
+		t.Errorf("mined %d blocks, want 1", len(node.mineArgs))
+	}
+}
+
+func TestRunSimulationMinesAtTargetTimestamp(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		timestamp int64
+		want      []interface{}
+	}{
+		{"next block", hexutil.EncodeUint64(fakeHead + 1), 0, []interface{}{float64(fakeHeadTime + 12)}},
+		{"later block", hexutil.EncodeUint64(fakeHead + 3), 0, []interface{}{float64(fakeHeadTime + 36)}},
+		{"requested timestamp", hexutil.EncodeUint64(fakeHead + 1), fakeHeadTime + 5, []interface{}{float64(fakeHeadTime + 5)}},
+		{"no target", "", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &fakeNode{}
+			cfg := newFakeNode(t, node)
+
+			_, err := RunSimulation(context.Background(), cfg, &pb.BundleRequest{
+				Txs:         []string{"0x01"},
+				TargetBlock: tt.target,
+				Timestamp:   tt.timestamp,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(node.mineArgs) != 1 || !reflect.DeepEqual(node.mineArgs[0], tt.want) {
+				t.Errorf("evm_mine params = %v, want %v", node.mineArgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSimulationChecksTimestampWindow(t *testing.T) {
+	// The bundle is mined at fakeHeadTime + 12.
+	const timestamp = fakeHeadTime + 12
+	tests := []struct {
+		name     string
+		min, max int64
+		want     string
+	}{
+		{"no window", 0, 0, ""},
+		{"inside the window", timestamp - 1, timestamp + 1, ""},
+		{"at both bounds", timestamp, timestamp, ""},
+		{"before minTimestamp", timestamp + 1, 0, "before minTimestamp"},
+		{"after maxTimestamp", 0, timestamp - 1, "after maxTimestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newFakeNode(t, &fakeNode{})
+
+			result, err := RunSimulation(context.Background(), cfg, &pb.BundleRequest{
+				Txs:          []string{"0x01"},
+				TargetBlock:  hexutil.EncodeUint64(fakeHead + 1),
+				MinTimestamp: tt.min,
+				MaxTimestamp: tt.max,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" && !result.Success {
+				t.Errorf("simulation failed: %s", result.Reason)
+			}
+			if tt.want != "" && (result.Success || !strings.Contains(result.Reason, tt.want)) {
+				t.Errorf("result = %v %q, want failure %q", result.Success, result.Reason, tt.want)
+			}
+		})
+	}
+}
@@ -24,7 +24,7 @@ func (s *Service) SimulateBundle(ctx context.Context, req *pb.BundleRequest) (*p
 	log.Printf("[Simulator] Simulating bundle %s targeting block %s (%d txs)",
 		req.BundleId, req.TargetBlock, len(req.Txs))
 
-	success, profit, err := RunSimulation(s.cfg, req.Txs)
+	result, err := RunSimulation(ctx, s.cfg, req)
 	latency := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -38,13 +38,51 @@ func (s *Service) SimulateBundle(ctx context.Context, req *pb.BundleRequest) (*p
 		}, nil
 	}
 
-	log.Printf("[Simulator] Bundle %s profit=%.6f ETH (success=%v)", req.BundleId, profit, success)
+	log.Printf("[Simulator] Bundle %s profit=%.6f ETH gas=%d (success=%v)",
+		req.BundleId, result.ProfitEth, result.GasUsed, result.Success)
 
 	return &pb.BundleResponse{
-		BundleId:  req.BundleId,
-		ProfitEth: profit,
-		LatencyMs: latency,
-		Success:   success,
-		Reason:    "",
+		BundleId:          req.BundleId,
+		ProfitEth:         result.ProfitEth,
+		LatencyMs:         latency,
+		Success:           result.Success,
+		Reason:            result.Reason,
+		CoinbaseDiff:      result.CoinbaseDiff.String(),
+		GasFees:           result.GasFees.String(),
+		EthSentToCoinbase: result.EthSentToCoinbase.String(),
+		Results:           toPBResults(result.Txs),
+		GasUsed:           result.GasUsed,
+		StateBlock:        result.StateBlock,
 	}, nil
 }
+
+func toPBResults(txs []TxResult) []*pb.TxResult {
+	results := make([]*pb.TxResult, 0, len(txs))
+	for _, tx := range txs {
+		logs := make([]*pb.Log, 0, len(tx.Logs))
+		for _, l := range tx.Logs {
+			topics := make([]string, 0, len(l.Topics))
+			for _, t := range l.Topics {
+				topics = append(topics, t.Hex())
+			}
+			logs = append(logs, &pb.Log{
+				Address: l.Address.Hex(),
+				Topics:  topics,
+				Data:    l.Data.String(),
+			})
+		}
+
+		results = append(results, &pb.TxResult{
+			TxHash:            tx.TxHash,
+			GasUsed:           tx.GasUsed,
+			Success:           tx.Success,
+			RevertReason:      tx.RevertReason,
+			Logs:              logs,
+			CoinbaseDiff:      tx.CoinbaseDiff.String(),
+			GasFees:           tx.GasFees.String(),
+			EthSentToCoinbase: tx.EthSentToCoinbase.String(),
+			Error:             tx.Error,
+		})
+	}
+	return results
+}
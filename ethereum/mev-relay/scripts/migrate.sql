@@ -1,3 +1,8 @@
+-- Schema of the relay's database. TimescaleDB runs this file when it
+-- initializes an empty volume, and the relay and builder run it again on
+-- startup, so every statement must be idempotent. Columns added to an
+-- existing table go in its ALTER TABLE as well as in its CREATE TABLE.
+
 -- Enable TimescaleDB
 CREATE EXTENSION IF NOT EXISTS timescaledb;
 
@@ -8,10 +13,50 @@ CREATE TABLE IF NOT EXISTS block_builds (
     included BOOLEAN,
     inclusion_reason TEXT,
     inclusion_latency_ms BIGINT,
+    slot BIGINT,
+    block_number BIGINT,
+    bundle_id TEXT,
+    num_tx INT,
+    gas_used BIGINT,
+    value NUMERIC(78, 0), -- wei paid to the proposer
+    builder_pubkey TEXT,
+    proposer_pubkey TEXT,
+    proposer_fee_recipient TEXT,
     timestamp TIMESTAMPTZ DEFAULT NOW() NOT NULL,
     PRIMARY KEY (id, timestamp)
 );
 SELECT create_hypertable('block_builds', 'timestamp', if_not_exists => TRUE);
+ALTER TABLE block_builds
+    ADD COLUMN IF NOT EXISTS slot BIGINT,
+    ADD COLUMN IF NOT EXISTS block_number BIGINT,
+    ADD COLUMN IF NOT EXISTS bundle_id TEXT,
+    ADD COLUMN IF NOT EXISTS num_tx INT,
+    ADD COLUMN IF NOT EXISTS gas_used BIGINT,
+    ADD COLUMN IF NOT EXISTS value NUMERIC(78, 0),
+    ADD COLUMN IF NOT EXISTS builder_pubkey TEXT,
+    ADD COLUMN IF NOT EXISTS proposer_pubkey TEXT,
+    ADD COLUMN IF NOT EXISTS proposer_fee_recipient TEXT;
+CREATE INDEX IF NOT EXISTS block_builds_slot_idx ON block_builds (slot DESC);
+
+-- payloads_delivered (Relay → payloads revealed to proposers)
+CREATE TABLE IF NOT EXISTS payloads_delivered (
+    id SERIAL,
+    slot BIGINT NOT NULL,
+    parent_hash TEXT,
+    block_hash TEXT NOT NULL,
+    builder_pubkey TEXT,
+    proposer_pubkey TEXT,
+    proposer_fee_recipient TEXT,
+    gas_limit BIGINT,
+    gas_used BIGINT,
+    value NUMERIC(78, 0),
+    block_number BIGINT,
+    num_tx INT,
+    delivered_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (id, delivered_at)
+);
+SELECT create_hypertable('payloads_delivered', 'delivered_at', if_not_exists => TRUE);
+CREATE INDEX IF NOT EXISTS payloads_delivered_slot_idx ON payloads_delivered (slot DESC);
 
 -- bundles (Relay → searcher submissions)
 CREATE TABLE IF NOT EXISTS bundles (
@@ -20,15 +65,19 @@ CREATE TABLE IF NOT EXISTS bundles (
     searcher TEXT,
     tx_count INT,
     target_block TEXT,
+    rejection_reason TEXT, -- set when the bundle was refused, e.g. by a deny-list
     arrival_time TIMESTAMPTZ DEFAULT NOW() NOT NULL,
     PRIMARY KEY (id, arrival_time)
 );
 SELECT create_hypertable('bundles', 'arrival_time', if_not_exists => TRUE);
+ALTER TABLE bundles ADD COLUMN IF NOT EXISTS rejection_reason TEXT;
+CREATE INDEX IF NOT EXISTS bundles_bundle_id_idx ON bundles (bundle_id);
 
 -- simulations (Simulator → results)
 CREATE TABLE IF NOT EXISTS simulations (
     id SERIAL,
     bundle_id TEXT NOT NULL,
+    searcher TEXT,
     profit_eth DOUBLE PRECISION,
     latency_ms BIGINT,
     success BOOLEAN,
@@ -37,6 +86,43 @@ CREATE TABLE IF NOT EXISTS simulations (
     PRIMARY KEY (id, simulated_at)
 );
 SELECT create_hypertable('simulations', 'simulated_at', if_not_exists => TRUE);
+ALTER TABLE simulations ADD COLUMN IF NOT EXISTS searcher TEXT;
+
+-- builder_submissions (Relay → each builder's answer to a forwarded bundle)
+CREATE TABLE IF NOT EXISTS builder_submissions (
+    id SERIAL,
+    bundle_id TEXT NOT NULL,
+    builder TEXT NOT NULL,
+    accepted BOOLEAN,
+    included BOOLEAN,
+    timed_out BOOLEAN,
+    latency_ms BIGINT,
+    block_hash TEXT,
+    error TEXT,
+    submitted_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (id, submitted_at)
+);
+SELECT create_hypertable('builder_submissions', 'submitted_at', if_not_exists => TRUE);
+CREATE INDEX IF NOT EXISTS builder_submissions_builder_idx ON builder_submissions (builder, submitted_at DESC);
+
+-- validator_registrations (Relay → latest builder API registration per validator)
+CREATE TABLE IF NOT EXISTS validator_registrations (
+    pubkey TEXT PRIMARY KEY,
+    fee_recipient TEXT NOT NULL,
+    gas_limit BIGINT NOT NULL,
+    timestamp BIGINT NOT NULL,
+    signature TEXT NOT NULL,
+    registered_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- deny_list (Relay admin API → denied addresses and banned searchers)
+CREATE TABLE IF NOT EXISTS deny_list (
+    kind TEXT NOT NULL, -- 'address' or 'searcher'
+    address TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    added_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (kind, address)
+);
 
 -- metrics (aggregated KPIs)
 CREATE TABLE IF NOT EXISTS metrics (
@@ -0,0 +1,10 @@
+// Package scripts holds the database schema so that services can apply it
+// on startup.
+package scripts
+
+import _ "embed"
+
+// Schema is the idempotent schema in migrate.sql.
+//
+//go:embed migrate.sql
+var Schema string
@@ -9,7 +9,7 @@ VALUES
 ('bundle-001', 'searcher-alpha', 2, '0x12A3B4'),
 ('bundle-002', 'searcher-beta', 3, '0x12A3B5');
 
-INSERT INTO simulations (bundle_id, profit_eth, latency_ms, success, reason)
+INSERT INTO simulations (bundle_id, searcher, profit_eth, latency_ms, success, reason)
 VALUES
-('bundle-001', 0.0043, 512, TRUE, ''),
-('bundle-002', 0.0021, 634, TRUE, '');
+('bundle-001', 'searcher-alpha', 0.0043, 512, TRUE, ''),
+('bundle-002', 'searcher-beta', 0.0021, 634, TRUE, '');